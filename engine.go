@@ -2,8 +2,10 @@ package liquid
 
 import (
 	"io"
+	"math/rand"
 
 	"github.com/osteele/liquid/filters"
+	"github.com/osteele/liquid/parser"
 	"github.com/osteele/liquid/render"
 	"github.com/osteele/liquid/tags"
 )
@@ -33,10 +35,30 @@ func (e *Engine) RegisterBlock(name string, td Renderer) {
 	})
 }
 
+// RegisterOpaqueBlock defines a block whose body is text that isn't parsed as
+// Liquid, as for {% raw %}. The Renderer can read the body verbatim with
+// Context.InnerString.
+func (e *Engine) RegisterOpaqueBlock(name string, td Renderer) {
+	e.cfg.AddOpaqueBlock(name).Renderer(func(w io.Writer, ctx render.Context) error {
+		s, err := td(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, s)
+		return err
+	})
+}
+
 // RegisterFilter defines a Liquid filter, for use as `{{ value | my_filter }}` or `{{ value | my_filter: arg }}`.
 //
 // A filter is a function that takes at least one input, and returns one or two outputs.
 // If it returns two outputs, the second must have type error.
+// A filter can be variadic, for example func(s string, args ...interface{}),
+// in which case it receives any number of trailing arguments.
+// If its first parameter has type FilterContext, such as
+// func(ctx FilterContext, s string) string, it receives the context there,
+// and its input as its second parameter. The context gives it access to the
+// template's variables.
 //
 // Examples:
 //
@@ -47,8 +69,26 @@ func (e *Engine) RegisterFilter(name string, fn interface{}) {
 	e.cfg.AddFilter(name, fn)
 }
 
+// RegisterFilterErr is like RegisterFilter, but returns an error instead of
+// panicking if fn isn't a valid filter function. The error names the filter
+// and describes the problem, so a host can report it to whoever supplied fn.
+func (e *Engine) RegisterFilterErr(name string, fn interface{}) error {
+	return e.cfg.AddFilterErr(name, fn)
+}
+
+// RegisterFilters defines several Liquid filters at once, from a map of names
+// to functions. It panics, naming the filter, if any of the functions isn't a
+// valid filter; in that case none of them are defined.
+func (e *Engine) RegisterFilters(filters map[string]interface{}) {
+	e.cfg.AddFilters(filters)
+}
+
 // RegisterTag defines a tag e.g. {% tag %}.
 //
+// The string that the Renderer returns replaces the tag in the output. A tag
+// can also set variables with Context.Set, as assign does; they are visible
+// to the rest of the template.
+//
 // Further examples are in https://github.com/osteele/gojekyll/blob/master/tags/tags.go
 func (e *Engine) RegisterTag(name string, td Renderer) {
 	// For simplicity, don't expose the two stage parsing/rendering process to clients.
@@ -70,6 +110,91 @@ func (e *Engine) StrictVariables() {
 	e.cfg.StrictVariables = true
 }
 
+// TrimTagNewlines causes the parser to remove the first newline after each tag,
+// so that a tag on a line by itself, such as {% if x %}, doesn't add a blank
+// line to the output. A -%} marker still trims all the whitespace after a tag.
+func (e *Engine) TrimTagNewlines() {
+	e.cfg.TrimTagNewlines = true
+}
+
+// Autoescape causes the join filter to HTML-escape each element of its input,
+// so that the output of an array is HTML-safe. The separator is written as is.
+func (e *Engine) Autoescape() {
+	e.cfg.Autoescape = true
+}
+
+// SetUnknownTagHandler sets a function that renders tags that haven't been
+// registered, instead of ParseTemplate returning an error. It is called with
+// the tag's name and arguments, and its result replaces the tag.
+func (e *Engine) SetUnknownTagHandler(fn func(name, params string) (string, error)) {
+	e.cfg.UnknownTagHandler = fn
+}
+
+// PassThroughUnknownTags causes tags that haven't been registered to be written
+// to the output unchanged, instead of ParseTemplate returning an error.
+func (e *Engine) PassThroughUnknownTags() {
+	e.cfg.PassThroughUnknownTags = true
+}
+
+// SetTemplateRoot restricts the files that tags such as include can read to
+// those within dir. Including a file outside it is a render error.
+func (e *Engine) SetTemplateRoot(dir string) {
+	e.cfg.TemplateRoot = dir
+}
+
+// SetSections configures the {% section %} and {% sections %} tags: where
+// section files are read from, each section's settings, and the sections in
+// each group.
+func (e *Engine) SetSections(sections render.Sections) {
+	e.cfg.Sections = sections
+}
+
+// RegisterFormType defines, or replaces, a form type for {% form "name" %}.
+func (e *Engine) RegisterFormType(name string, ft render.FormType) {
+	if e.cfg.FormTypes == nil {
+		e.cfg.FormTypes = map[string]render.FormType{}
+	}
+	e.cfg.FormTypes[name] = ft
+}
+
+// SetMarkdownConverter sets the function that the markdownify filter uses to
+// convert Markdown to HTML. Without one, markdownify returns its input
+// unchanged.
+func (e *Engine) SetMarkdownConverter(fn func(string) string) {
+	e.cfg.Markdown = fn
+}
+
+// SetRandomSource sets the source of randomness for the sample and shuffle
+// filters. By default, they use a time-seeded source.
+func (e *Engine) SetRandomSource(r *rand.Rand) {
+	e.cfg.Rand = r
+}
+
+// SetGlobals sets variables that are visible to every template that the
+// engine renders. The bindings that are passed to a render take precedence.
+func (e *Engine) SetGlobals(globals map[string]interface{}) {
+	e.cfg.Globals = globals
+}
+
+// SetMaxIterations limits the total number of loop iterations in a single
+// render, across all its loops. Zero, the default, means no limit.
+func (e *Engine) SetMaxIterations(n int) {
+	e.cfg.MaxIterations = n
+}
+
+// SetMaxOutputBytes limits the size of the output of a single render, and of
+// each buffer that a tag such as capture or include renders into. Zero, the
+// default, means no limit.
+func (e *Engine) SetMaxOutputBytes(n int) {
+	e.cfg.MaxOutputBytes = n
+}
+
+// Config returns a copy of the engine's configuration, for example to check
+// templates against it with Template.Validate.
+func (e *Engine) Config() Config {
+	return e.cfg.Clone()
+}
+
 // ParseTemplate creates a new Template using the engine configuration.
 func (e *Engine) ParseTemplate(source []byte) (*Template, SourceError) {
 	return newTemplate(&e.cfg, source, "", 0)
@@ -120,10 +245,17 @@ func (e *Engine) ParseAndRenderString(source string, b Bindings) (string, Source
 // ParseTemplate, ParseTemplateLocation, ParseAndRender, or ParseAndRenderString. An empty delimiter
 // stands for the corresponding default: objectLeft = {{, objectRight = }}, tagLeft = {% , tagRight = %}
 func (e *Engine) Delims(objectLeft, objectRight, tagLeft, tagRight string) *Engine {
-	e.cfg.Delims = []string{objectLeft, objectRight, tagLeft, tagRight}
+	e.cfg.SetTagDelimiters(objectLeft, objectRight, tagLeft, tagRight)
 	return e
 }
 
+// Format returns source with its objects and tags written in a consistent
+// style, such as {{ x }} and {% if x %}, using the engine's delimiters. Text
+// and the contents of raw blocks are left unchanged.
+func (e *Engine) Format(source string) string {
+	return parser.Format(source, e.cfg.Delims)
+}
+
 // ParseTemplateAndCache is the same as ParseTemplateLocation, except that the
 // source location is used for error reporting and for the {% include %} tag.
 // If parsing is successful, provided source is then cached, and can be retrieved
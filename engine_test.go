@@ -3,11 +3,15 @@ package liquid
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"io"
+	"math/rand"
 	"strings"
 	"testing"
 
+	"github.com/osteele/liquid/render"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,6 +23,7 @@ var liquidTests = []struct{ in, expected string }{
 	{`{{ page.title }}`, "Introduction"},
 	{`{% if x %}true{% endif %}`, "true"},
 	{`{{ "upper" | upcase }}`, "UPPER"},
+	{`{% for e in page | entries %}{{ e.key }}={{ e.value }}{% endfor %}`, "title=Introduction"},
 }
 
 var testBindings = map[string]interface{}{
@@ -89,6 +94,386 @@ func TestEngine_ParseAndRenderString_struct(t *testing.T) {
 	require.Equal(t, "hello", str)
 }
 
+func TestEngine_ParseAndRenderString_lazy_bindings(t *testing.T) {
+	calls := 0
+	params := map[string]interface{}{
+		"user": func() interface{} {
+			calls++
+			return map[string]interface{}{"name": "Ann", "roles": []string{"admin", "editor"}}
+		},
+	}
+	engine := NewEngine()
+
+	str, err := engine.ParseAndRenderString("no reference", params)
+	require.NoError(t, err)
+	require.Equal(t, "no reference", str)
+	require.Equal(t, 0, calls)
+
+	template := "{{ user.name }}:{% for r in user.roles %}{{ r }}{% endfor %}:{{ user.name }}"
+	str, err = engine.ParseAndRenderString(template, params)
+	require.NoError(t, err)
+	require.Equal(t, "Ann:admineditor:Ann", str)
+	require.Equal(t, 1, calls)
+
+	// each render calls the function afresh
+	_, err = engine.ParseAndRenderString(template, params)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestEngine_ParseAndRenderString_missing_properties(t *testing.T) {
+	params := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{"c": "value"},
+			"s": "string",
+		},
+		"int_keys": map[int]string{1: "one"},
+		"ptr":      (*testStruct)(nil),
+	}
+	tests := []struct{ in, expected string }{
+		{`{{ a.b.c | default: "x" }}`, "value"},
+		{`{{ missing.b.c | default: "x" }}`, "x"},
+		{`{{ a.missing.c | default: "x" }}`, "x"},
+		{`{{ a.b.missing | default: "x" }}`, "x"},
+		{`{{ a.b.c.missing | default: "x" }}`, "x"},
+		{`{{ a.s.missing.c | default: "x" }}`, "x"},
+		{`{{ a["missing"]["c"] | default: "x" }}`, "x"},
+		{`{{ a.b.c[5] | default: "x" }}`, "x"},
+		{`{{ int_keys.missing.c | default: "x" }}`, "x"},
+		{`{{ ptr.Text.missing | default: "x" }}`, "x"},
+	}
+	engine := NewEngine()
+	for _, test := range tests {
+		str, err := engine.ParseAndRenderString(test.in, params)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, str, test.in)
+	}
+}
+
+func TestEngine_RegisterTag_set(t *testing.T) {
+	engine := NewEngine()
+	engine.RegisterTag("remember", func(c render.Context) (string, error) {
+		c.Set("remembered", c.Get(c.TagArgs()))
+		return "", nil
+	})
+	bindings := map[string]interface{}{"items": []string{"a", "b"}}
+	tests := []struct{ in, expected string }{
+		{`{% remember items %}{{ remembered | join }}`, "a b"},
+		{`{{ remembered }}{% remember items %}{{ remembered.size }}`, "2"},
+		// a variable that a tag sets within a loop outlives the loop, but the
+		// loop variable doesn't
+		{`{% for item in items %}{% remember item %}{% endfor %}{{ remembered }}/{{ item }}`, "b/"},
+	}
+	for _, test := range tests {
+		str, err := engine.ParseAndRenderString(test.in, bindings)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, str, test.in)
+	}
+}
+
+func TestEngine_RegisterTag_set_and_output(t *testing.T) {
+	engine := NewEngine()
+	// {% let name = expr %} sets the variable, and writes a confirmation
+	engine.RegisterTag("let", func(c render.Context) (string, error) {
+		parts := strings.SplitN(c.TagArgs(), "=", 2)
+		name := strings.TrimSpace(parts[0])
+		value, err := c.EvaluateString(parts[1])
+		if err != nil {
+			return "", err
+		}
+		c.Set(name, value)
+		return fmt.Sprintf("[%s set]", name), nil
+	})
+	engine.RegisterBlock("letblock", func(c render.Context) (string, error) {
+		s, err := c.InnerString()
+		if err != nil {
+			return "", err
+		}
+		c.Set(c.TagArgs(), strings.TrimSpace(s))
+		return "[" + c.TagArgs() + " set]", nil
+	})
+	bindings := map[string]interface{}{"items": []string{"a", "b"}}
+	tests := []struct{ in, expected string }{
+		{`{% let x = items | join: "," %}:{{ x }}`, "[x set]:a,b"},
+		{`{{ x }}{% let x = 1 %}{{ x | plus: 1 }}`, "[x set]2"},
+		{`{% for i in items %}{% let last = i %}{% endfor %}{{ last }}`, "[last set][last set]b"},
+		{`{% if true %}{% let x = 2 %}{% endif %}{{ x }}`, "[x set]2"},
+		{`{% letblock y %} {% let x = 3 %} {% endletblock %}{{ y }}/{{ x }}`, "[y set][x set]/3"},
+	}
+	for _, test := range tests {
+		str, err := engine.ParseAndRenderString(test.in, bindings)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, str, test.in)
+	}
+}
+
+func TestEngine_RegisterOpaqueBlock(t *testing.T) {
+	engine := NewEngine()
+	// {% code %} writes its body HTML-escaped, without evaluating it
+	engine.RegisterOpaqueBlock("code", func(c render.Context) (string, error) {
+		s, err := c.InnerString()
+		if err != nil {
+			return "", err
+		}
+		return "<pre>" + html.EscapeString(s) + "</pre>", nil
+	})
+	tests := []struct{ in, expected string }{
+		{`{% code %}{{ x }} {% if x %}<b>{% endcode %}`, "<pre>{{ x }} {% if x %}&lt;b&gt;</pre>"},
+		{`{% if x %}{% code %}{%- endif -%}{% endcode %}{% endif %}`, "<pre>{%- endif -%}</pre>"},
+		{`a {%- code -%} b {%- endcode -%} c`, "a<pre>b</pre>c"},
+		{`{% code %}{% endcode %}`, "<pre></pre>"},
+	}
+	for _, test := range tests {
+		str, err := engine.ParseAndRenderString(test.in, map[string]interface{}{"x": 1})
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, str, test.in)
+	}
+
+	_, err := engine.ParseString(`{% code %}{{ x }}`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `unterminated "code" block`)
+}
+
+func TestEngine_ParseAndRenderString_push(t *testing.T) {
+	engine := NewEngine()
+	tests := []struct{ in, expected string }{
+		{`{% assign list = "" | split: "," %}{% for i in (1..4) %}{% assign list = list | push: i %}{% endfor %}{{ list | join: "," }}`, "1,2,3,4"},
+		// each result is independent of the others that are pushed to the same
+		// base array
+		{`{% assign base = "a,b,c" | split: "," | push: "d" %}{% for i in (1..3) %}{% assign list = base | push: i %}{{ list | join: "" }};{% endfor %}{{ base | join: "" }}`, "abcd1;abcd2;abcd3;abcd"},
+	}
+	for _, test := range tests {
+		str, err := engine.ParseAndRenderString(test.in, emptyBindings)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, str, test.in)
+	}
+
+	// pushing doesn't modify a bound array
+	base := make([]interface{}, 1, 10)
+	base[0] = "a"
+	bindings := map[string]interface{}{"base": base}
+	str, err := engine.ParseAndRenderString(`{{ base | push: "b" | join }}/{{ base | push: "c" | join }}`, bindings)
+	require.NoError(t, err)
+	require.Equal(t, "a b/a c", str)
+	require.Len(t, base, 1)
+}
+
+func TestEngine_ParseAndRenderString_trim_blocks(t *testing.T) {
+	engine := NewEngine()
+	tests := []struct{ in, expected string }{
+		{"a\n{%- for i in (1..3) -%}\n{{ i }}\n{%- endfor -%}\nb", "a123b"},
+		{"a\n{%- if true -%}\nx\n{%- else -%}\ny\n{%- endif -%}\nb", "axb"},
+		{"a\n{%- if false -%}\nx\n{%- else -%}\ny\n{%- endif -%}\nb", "ayb"},
+		{"a\n{%- case 1 -%}\n{%- when 1 -%}\n one \n{%- endcase -%}\nb", "aoneb"},
+		{"a\n{%- unless false -%}\n u \n{%- endunless -%}\nb", "aub"},
+		{"a\n{%- capture c -%}\n x \n{%- endcapture -%}\n[{{ c }}]", "a[x]"},
+		{"a\n{%- comment -%}\n x \n{%- endcomment -%}\nb", "ab"},
+		{"a\n{%- raw -%}\n{{- x -}}\n{%- endraw -%}\nb", "a{{- x -}}b"},
+		{"a\n{%- liquid\n  assign x = 1\n-%}\n{{ x }}", "a1"},
+		{"<ul>\n{% for i in (1..2) %}\n  <li>{{ i }}</li>\n{%- endfor %}\n</ul>", "<ul>\n\n  <li>1</li>\n  <li>2</li>\n</ul>"},
+		{"{% for i in (1..2) %}\n  {%- if i == 1 -%}\n one\n  {%- endif %}\n{%- endfor %}", "one"},
+		// trimming applies to text, not to the output of the tag or its body
+		{"{% if true -%}\n{{ ' x ' }}{%- endif %}", " x "},
+		{"{% if true %}\n{%- endif %}\nb", "\nb"},
+	}
+	for _, test := range tests {
+		str, err := engine.ParseAndRenderString(test.in, emptyBindings)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, str, test.in)
+	}
+}
+
+func TestEngine_TrimTagNewlines(t *testing.T) {
+	tests := []struct{ in, preserved, trimmed string }{
+		{"{% if true %}\nx\n{% endif %}\ny", "\nx\n\ny", "x\ny"},
+		{"<ul>\n{% for i in (1..2) %}\n<li>{{ i }}</li>\n{% endfor %}\n</ul>", "<ul>\n\n<li>1</li>\n\n<li>2</li>\n\n</ul>", "<ul>\n<li>1</li>\n<li>2</li>\n</ul>"},
+		{"{% assign x = 1 %}\r\n{{ x }}\n\n", "\r\n1\n\n", "1\n\n"},
+		{"{% if true %}\n\nx{% endif %}", "\n\nx", "\nx"},
+		{"{% if true %}  \nx{% endif %}", "  \nx", "  \nx"},
+		{"{% if true -%}\n\n x{% endif %}", "x", "x"},
+		{"{{ 1 }}\n{% # comment %}\n{{ 2 }}", "1\n\n2", "1\n2"},
+		{"{% raw %}\n{% if %}\n{% endraw %}\n", "\n{% if %}\n\n", "{% if %}\n"},
+	}
+	for _, test := range tests {
+		engine := NewEngine()
+		str, err := engine.ParseAndRenderString(test.in, emptyBindings)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.preserved, str, test.in)
+
+		engine.TrimTagNewlines()
+		str, err = engine.ParseAndRenderString(test.in, emptyBindings)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.trimmed, str, test.in)
+	}
+}
+
+func TestEngine_ParseAndRenderString_liquid_tag(t *testing.T) {
+	engine := NewEngine()
+	tests := []struct{ in, expected string }{
+		{"{% liquid\n  # a comment\n  assign x = 2\n  # another comment\n  echo x | plus: 1\n%}", "3"},
+		{"{% liquid\n  for i in (1..3)\n    # skip the second\n    if i != 2\n      echo i\n    endif\n  endfor\n%}", "13"},
+		{"{% liquid\n  # only a comment\n%}", ""},
+		{"a{% # an inline comment %}b", "ab"},
+		{"a {%- # an inline comment -%} b", "ab"},
+	}
+	for _, test := range tests {
+		str, err := engine.ParseAndRenderString(test.in, emptyBindings)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, str, test.in)
+	}
+
+	_, err := engine.ParseString("{% liquid\n  assign x = 1\n\n  echo x |\n%}")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "line 3")
+}
+
+func TestEngine_ParseAndRenderString_assign_types(t *testing.T) {
+	engine := NewEngine()
+	tests := []struct{ in, expected string }{
+		// assign preserves the type of the literal
+		{`{% assign n = 5 %}{{ n | json }}`, `5`},
+		{`{% assign n = "5" %}{{ n | json }}`, `"5"`},
+		{`{% assign n = 5 %}{% if n == 5 %}number{% else %}string{% endif %}`, "number"},
+		{`{% assign n = "5" %}{% if n == 5 %}number{% else %}string{% endif %}`, "string"},
+		{`{% assign n = 5 %}{{ n | append: "0" }}`, "50"},
+		{`{% assign n = "5" %}{{ n | append: "0" }}`, "50"},
+		// arithmetic coerces a numeric string
+		{`{% assign n = 5 %}{{ n | plus: 1 }}`, "6"},
+		{`{% assign n = "5" %}{{ n | plus: 1 }}`, "6"},
+		{`{% assign n = "5" %}{{ n | plus: "1" }}`, "6"},
+		{`{% assign n = "5" %}{{ n | plus: 1 | json }}`, "6"},
+		{`{% assign n = "5.5" %}{{ n | plus: 1 }}`, "6.5"},
+		{`{% assign n = "5" %}{{ n | times: 2 }} {{ n | minus: 2 }} {{ n | modulo: 2 }}`, "10 3 1"},
+		{`{% assign n = 10 %}{{ n | divided_by: 4 }} {{ n | divided_by: "4" }}`, "2 2"},
+		{`{% assign n = "10" %}{{ n | divided_by: 4 }} {{ n | divided_by: "4" }}`, "2 2"},
+		// an array-valued filter result stays an array
+		{`{% assign parts = "a,b,c" | split: "," %}{% for p in parts %}[{{ p }}]{% endfor %}`, "[a][b][c]"},
+		{`{% assign parts = "a,b,c" | split: "," %}{{ parts.size }} {{ parts.first }} {{ parts[1] }} {{ parts | last }}`, "3 a b c"},
+		{`{% assign parts = "a,b,c" | split: "," %}{% for p in parts reversed limit: 2 %}{{ p }}{% endfor %}`, "cb"},
+		{`{% assign parts = "b,c,a" | split: "," | sort %}{% for p in parts %}{{ forloop.index }}{{ p }}{% endfor %}`, "1a2b3c"},
+		{`{% capture s %}x-y{% endcapture %}{% assign parts = s | split: "-" %}{% for p in parts %}[{{ p }}]{% endfor %}`, "[x][y]"},
+		{`{% assign parts = "" | split: "," %}{% for p in parts %}[{{ p }}]{% endfor %}{{ parts.size }}`, "0"},
+		{`{% assign parts = "a,b" | split: "," %}{% assign n = parts | size %}{{ n | plus: 1 }}`, "3"},
+	}
+	for _, test := range tests {
+		out, err := engine.ParseAndRenderString(test.in, emptyBindings)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, out, test.in)
+	}
+}
+
+// countingDrop counts the calls to its ToLiquid method.
+type countingDrop struct{ calls *int }
+
+func (d countingDrop) ToLiquid() interface{} {
+	*d.calls++
+	return []string{"c", "b", "a"}
+}
+
+func TestEngine_ParseAndRenderString_assign_value(t *testing.T) {
+	engine := NewEngine()
+	bindings := map[string]interface{}{"list": []string{"a", "b", "c", "d", "e", "f", "g", "h"}}
+
+	// an assigned array is iterated in the same order each time
+	out, err := engine.ParseAndRenderString(
+		`{% assign x = list | reverse %}{% for i in x %}{{ i }}{% endfor %}/{% for i in x %}{{ i }}{% endfor %}`, bindings)
+	require.NoError(t, err)
+	require.Equal(t, "hgfedcba/hgfedcba", out)
+	out, err = engine.ParseAndRenderString(
+		`{% assign x = list | shuffle %}{% for i in x %}{{ i }}{% endfor %}/{% for i in x %}{{ i }}{% endfor %}`, bindings)
+	require.NoError(t, err)
+	halves := strings.Split(out, "/")
+	require.Equal(t, halves[0], halves[1])
+
+	// a drop that a filter returns is resolved once
+	calls := 0
+	engine.RegisterFilter("drop", func(interface{}) interface{} { return countingDrop{&calls} })
+	out, err = engine.ParseAndRenderString(
+		`{% assign x = list | drop %}{% for i in x %}{{ i }}{% endfor %}{{ x.size }}{{ x | join }}`, bindings)
+	require.NoError(t, err)
+	require.Equal(t, "cba3c b a", out)
+	require.Equal(t, 1, calls)
+}
+
+func TestEngine_RegisterFilters(t *testing.T) {
+	engine := NewEngine()
+	engine.RegisterFilters(map[string]interface{}{
+		"shout":   func(s string) string { return strings.ToUpper(s) + "!" },
+		"whisper": func(s string) string { return strings.ToLower(s) + "…" },
+	})
+	out, err := engine.ParseAndRenderString(`{{ "Hi" | shout }} {{ "Hi" | whisper }}`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "HI! hi…", out)
+}
+
+func TestEngine_RegisterFilterErr(t *testing.T) {
+	engine := NewEngine()
+	require.NoError(t, engine.RegisterFilterErr("shout", func(s string) string { return strings.ToUpper(s) + "!" }))
+	out, err := engine.ParseAndRenderString(`{{ "Hi" | shout }}`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "HI!", out)
+
+	require.EqualError(t, engine.RegisterFilterErr("shout", "HI!"), `filter "shout": a filter must be a function; got string`)
+	require.EqualError(t, engine.RegisterFilterErr("pair", func(s string) (string, string) { return s, s }),
+		`filter "pair": a filter's second output must be type error; got string`)
+
+	// a rejected filter doesn't replace the existing one
+	out, err = engine.ParseAndRenderString(`{{ "Hi" | shout }}`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "HI!", out)
+}
+
+func TestEngine_RegisterFilter_context(t *testing.T) {
+	engine := NewEngine()
+	engine.RegisterFilter("t", func(ctx FilterContext, key string) string {
+		locale, _ := ctx.Get("locale")
+		return fmt.Sprintf("%s.%s", locale, key)
+	})
+	out, err := engine.ParseAndRenderString(`{{ "title" | t }} {% assign locale = "fr" %}{{ "title" | t | upcase }}`,
+		map[string]interface{}{"locale": "en"})
+	require.NoError(t, err)
+	require.Equal(t, "en.title FR.TITLE", out)
+}
+
+func TestEngine_SetMarkdownConverter(t *testing.T) {
+	engine := NewEngine()
+	str, err := engine.ParseAndRenderString(`{{ "# title" | markdownify }}`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "# title", str)
+
+	engine.SetMarkdownConverter(func(s string) string {
+		return "<h1>" + strings.TrimPrefix(s, "# ") + "</h1>"
+	})
+	str, err = engine.ParseAndRenderString(`{{ "# title" | markdownify }}`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "<h1>title</h1>", str)
+}
+
+func TestEngine_Autoescape(t *testing.T) {
+	engine := NewEngine()
+	bindings := map[string]interface{}{"items": []string{"<a>", "<b>"}}
+	str, err := engine.ParseAndRenderString(`{{ items | join: ", " }}`, bindings)
+	require.NoError(t, err)
+	require.Equal(t, "<a>, <b>", str)
+
+	engine.Autoescape()
+	str, err = engine.ParseAndRenderString(`{{ items | join: ", " }}`, bindings)
+	require.NoError(t, err)
+	require.Equal(t, "&lt;a&gt;, &lt;b&gt;", str)
+}
+
+func TestEngine_SetRandomSource(t *testing.T) {
+	engine := NewEngine()
+	bindings := map[string]interface{}{"a": []int{1, 2, 3, 4, 5}}
+	render := func() string {
+		engine.SetRandomSource(rand.New(rand.NewSource(1)))
+		str, err := engine.ParseAndRenderString(`{{ a | shuffle | join }}`, bindings)
+		require.NoError(t, err)
+		return str
+	}
+	require.Equal(t, render(), render())
+}
+
 func TestEngine_ParseAndRender_errors(t *testing.T) {
 	_, err := NewEngine().ParseAndRenderString("{{ syntax error }}", emptyBindings)
 	require.Error(t, err)
@@ -121,6 +506,183 @@ func BenchmarkEngine_Parse(b *testing.B) {
 	}
 }
 
+func TestEngine_ParseAndRender_error_types(t *testing.T) {
+	engine := NewEngine()
+	_, err := engine.ParseString("line 0\n{{ syntax error }}")
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	require.Equal(t, 1, pe.LineNumber())
+
+	_, err = engine.ParseAndRenderString(`{{ 1 | plus: "two" }}`, emptyBindings)
+	var re *RenderError
+	require.True(t, errors.As(err, &re))
+	require.False(t, errors.As(err, &pe))
+	var fe *FilterArgumentError
+	require.True(t, errors.As(err, &fe))
+	require.Equal(t, "plus", fe.Filter)
+	require.Equal(t, 1, fe.Arg)
+
+	engine.StrictVariables()
+	_, err = engine.ParseAndRenderString(`{{ page.title }}`, emptyBindings)
+	require.True(t, errors.As(err, &re))
+	var ue *UndefinedVariableError
+	require.True(t, errors.As(err, &ue))
+	require.Equal(t, "page.title", ue.Name)
+}
+
+func TestEngine_ParseAndRender_wrapped_errors(t *testing.T) {
+	engine := NewEngine()
+	for _, src := range []string{
+		`{{ "x" | undefined_filter }}`,
+		`{% if "x" | undefined_filter %}{% endif %}`,
+		`{% for x in "x" | undefined_filter %}{% endfor %}`,
+	} {
+		_, err := engine.ParseAndRenderString(src, emptyBindings)
+		require.Errorf(t, err, src)
+		require.Truef(t, errors.Is(err, ErrUndefinedFilter), src)
+	}
+
+	errFilter := errors.New("filter error")
+	engine.RegisterFilter("fails", func(string) (string, error) { return "", errFilter })
+	_, err := engine.ParseAndRenderString(`{{ "x" | fails }}`, emptyBindings)
+	require.True(t, errors.Is(err, errFilter))
+	require.False(t, errors.Is(err, ErrUndefinedFilter))
+
+	tpl, err := engine.ParseString(`{{ "x" | undefined_filter }}`)
+	require.NoError(t, err)
+	errs := tpl.Validate(engine.Config())
+	require.Len(t, errs, 1)
+	require.True(t, errors.Is(errs[0], ErrUndefinedFilter))
+}
+
+func TestEngine_limits(t *testing.T) {
+	engine := NewEngine()
+	engine.SetMaxIterations(100)
+	engine.SetMaxOutputBytes(1000)
+	_, err := engine.ParseAndRenderString(`{% for i in (1..10) %}{% for j in (1..10) %}{% endfor %}{% endfor %}`, emptyBindings)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "loop iterations")
+	_, err = engine.ParseAndRenderString(`{% for i in (1..100) %}{{ "0123456789" }}{% endfor %}`, emptyBindings)
+	require.NoError(t, err)
+	_, err = engine.ParseAndRenderString(`{% for i in (1..100) %}{{ "0123456789" }}{% endfor %}!`, emptyBindings)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bytes of output")
+
+	// output that is captured, and never written, is limited too
+	_, err = engine.ParseAndRenderString(`{% capture x %}{% for i in (1..90) %}{{ "0123456789" }}{% endfor %}{% endcapture %}{{ x | size }}`, emptyBindings)
+	require.NoError(t, err)
+	_, err = engine.ParseAndRenderString(`{% capture x %}{% for i in (1..100) %}{{ "0123456789" }}{% endfor %}!{% endcapture %}{{ x | size }}`, emptyBindings)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bytes of output")
+}
+
+func TestEngine_limits_buffered(t *testing.T) {
+	// output that is buffered and then written counts once against the limit
+	engine := NewEngine()
+	engine.SetMaxOutputBytes(100)
+	_, err := engine.ParseTemplateAndCache([]byte(strings.Repeat("x", 60)), "a.html", 1)
+	require.NoError(t, err)
+	_, err = engine.ParseTemplateAndCache([]byte(`{% include "a.html" %}`), "b.html", 1)
+	require.NoError(t, err)
+
+	out, err := engine.ParseAndRenderString(`{% include "a.html" %}`, emptyBindings)
+	require.NoError(t, err)
+	require.Len(t, out, 60)
+	out, err = engine.ParseAndRenderString(`{% include "b.html" %}`, emptyBindings)
+	require.NoError(t, err)
+	require.Len(t, out, 60)
+	out, err = engine.ParseAndRenderString(`{% capture x %}`+strings.Repeat("y", 61)+`{% endcapture %}{{ x }}`, emptyBindings)
+	require.NoError(t, err)
+	require.Len(t, out, 61)
+
+	_, err = engine.ParseAndRenderString(`{% include "a.html" %}{% include "a.html" %}`, emptyBindings)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bytes of output")
+}
+
+func TestEngine_Format(t *testing.T) {
+	engine := NewEngine()
+	src := `{%if x%}{{x|upcase}}{%endif%}`
+	formatted := engine.Format(src)
+	require.Equal(t, `{% if x %}{{ x|upcase }}{% endif %}`, formatted)
+	bindings := map[string]interface{}{"x": "a"}
+	expected, err := engine.ParseAndRenderString(src, bindings)
+	require.NoError(t, err)
+	out, err := engine.ParseAndRenderString(formatted, bindings)
+	require.NoError(t, err)
+	require.Equal(t, expected, out)
+
+	engine.Delims("<<", ">>", "<%", "%>")
+	require.Equal(t, `<% if x %><< x >><% endif %>`, engine.Format(`<%if x%><<x>><%endif%>`))
+}
+
+func TestEngine_Delims(t *testing.T) {
+	engine := NewEngine()
+	engine.Delims("[[", "]]", "[%", "%]")
+	bindings := map[string]interface{}{"items": []string{"a", "b"}}
+	out, err := engine.ParseAndRenderString(`[% for x in items %][[ x | upcase ]][% endfor %] {{ x }} {% raw %}`, bindings)
+	require.NoError(t, err)
+	require.Equal(t, "AB {{ x }} {% raw %}", out)
+
+	// an empty delimiter stands for the default
+	engine = NewEngine()
+	engine.Delims("", "", "[%", "%]")
+	out, err = engine.ParseAndRenderString(`[% if true %]{{ "ok" }}[% endif %]{% if %}`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "ok{% if %}", out)
+}
+
+func TestEngine_Delims_inlineComments(t *testing.T) {
+	// with the default delimiters, {#…#} is an inline comment
+	out, err := NewEngine().ParseAndRenderString(`a {# b #} c; color: {#fff#};`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "a  c; color: ;", out)
+
+	// with custom delimiters, it's text; {% # … %} becomes <% # … %>
+	engine := NewEngine()
+	engine.Delims("<<", ">>", "<%", "%>")
+	out, err = engine.ParseAndRenderString(`a {# b #} c; color: {#fff#};<% # note %>`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "a {# b #} c; color: {#fff#};", out)
+}
+
+func TestEngine_SetGlobals(t *testing.T) {
+	engine := NewEngine()
+	engine.SetGlobals(map[string]interface{}{"site": map[string]interface{}{"title": "Blog"}, "x": 1})
+	out, err := engine.ParseAndRenderString(`{{ site.title }} {{ x }}`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "Blog 1", out)
+	out, err = engine.ParseAndRenderString(`{{ site.title }} {{ x }}`, map[string]interface{}{"x": 2})
+	require.NoError(t, err)
+	require.Equal(t, "Blog 2", out)
+	out, err = engine.ParseAndRenderString(`{% assign x = 3 %}{{ x }}`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "3", out)
+	out, err = engine.ParseAndRenderString(`{{ x }}`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "1", out)
+}
+
+func TestEngine_unknownTags(t *testing.T) {
+	src := `{% if true %}{% widget %}{% render 'card' %}{% endif %}`
+	engine := NewEngine()
+	_, err := engine.ParseString(src)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "undefined tag")
+
+	engine.PassThroughUnknownTags()
+	out, err := engine.ParseAndRenderString(src, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, `{% widget %}{% render 'card' %}`, out)
+
+	engine.SetUnknownTagHandler(func(name, params string) (string, error) {
+		return "[" + name + " " + params + "]", nil
+	})
+	out, err = engine.ParseAndRenderString(src, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, `[widget ][render 'card']`, out)
+}
+
 func TestEngine_ParseTemplateAndCache(t *testing.T) {
 	// Given two templates...
 	templateA := []byte("Foo")
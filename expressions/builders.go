@@ -21,6 +21,9 @@ func makeContainsExpr(e1, e2 func(Context) values.Value) func(Context) values.Va
 func makeFilter(fn valueFn, name string, args []valueFn) valueFn {
 	return func(ctx Context) values.Value {
 		result, err := ctx.ApplyFilter(name, fn, args)
+		if e, ok := err.(*FilterArgumentError); ok {
+			panic(e)
+		}
 		if err != nil {
 			panic(FilterError{
 				FilterName: name,
@@ -1,11 +1,89 @@
 package expressions
 
+import (
+	"html"
+	"math/rand"
+	"sync"
+	"time"
+)
+
 // Config holds configuration information for expression interpretation.
 type Config struct {
 	filters map[string]interface{}
+	// Markdown converts Markdown to HTML, for the markdownify filter. If it is
+	// nil, markdownify returns its input unchanged.
+	Markdown func(string) string
+	// Rand is the source of randomness for the sample and shuffle filters. If
+	// it is nil, they use a time-seeded source. Templates can be rendered
+	// concurrently, so uses of Rand by a Config that is made by NewConfig, and
+	// its clones, are serialized (see WithRandomSource). Configs that aren't
+	// clones of each other shouldn't share a Rand.
+	Rand *rand.Rand
+	// randMu serializes the uses of Rand. It's shared with clones, which
+	// share Rand unless they set their own.
+	randMu *sync.Mutex
+	// Autoescape causes the join filter to HTML-escape each element of its
+	// input. The separator isn't escaped.
+	Autoescape bool
+	// MaxOutputBytes limits the size of the output of a render, and of each
+	// buffer that a tag such as capture or include renders into. Filters that
+	// can build large strings, such as times, respect it too. Zero means no
+	// limit.
+	MaxOutputBytes int
 }
 
 // NewConfig creates a new Config.
 func NewConfig() Config {
-	return Config{}
+	return Config{randMu: new(sync.Mutex)}
+}
+
+// Clone returns a copy of the configuration. Filters that are added to the
+// copy are not visible to the original.
+func (c Config) Clone() Config {
+	clone := c
+	clone.filters = make(map[string]interface{}, len(c.filters))
+	for k, v := range c.filters {
+		clone.filters[k] = v
+	}
+	return clone
+}
+
+// ConvertMarkdown converts s from Markdown to HTML using c.Markdown. Filters
+// reach it through their FilterContext, so that it uses the configuration of
+// the current render.
+func (c Config) ConvertMarkdown(s string) string {
+	if c.Markdown == nil {
+		return s
+	}
+	return c.Markdown(s)
+}
+
+// OutputLimit returns c.MaxOutputBytes. Filters reach it through their
+// FilterContext.
+func (c Config) OutputLimit() int {
+	return c.MaxOutputBytes
+}
+
+// AutoescapeHTML returns s, HTML-escaped if c.Autoescape is set. Like
+// ConvertMarkdown, filters reach it through their FilterContext.
+func (c Config) AutoescapeHTML(s string) string {
+	if !c.Autoescape {
+		return s
+	}
+	return html.EscapeString(s)
+}
+
+// WithRandomSource calls fn with c.Rand, while holding the configuration's
+// lock, since a rand.Rand isn't safe for concurrent use. If Rand is nil, fn is
+// called with a new time-seeded source, without the lock.
+func (c Config) WithRandomSource(fn func(*rand.Rand)) {
+	if c.Rand == nil {
+		fn(rand.New(rand.NewSource(time.Now().UnixNano()))) // nolint: gosec
+		return
+	}
+	if c.randMu != nil {
+		c.randMu.Lock()
+		defer c.randMu.Unlock()
+	}
+	fn(c.Rand)
 }
@@ -1,6 +1,10 @@
 package expressions
 
-import "github.com/osteele/liquid/values"
+import (
+	"reflect"
+
+	"github.com/osteele/liquid/values"
+)
 
 // Context is the expression evaluation context. It maps variables names to values.
 type Context interface {
@@ -8,18 +12,24 @@ type Context interface {
 	// Clone returns a copy with a new variable binding map
 	// (so that copy.Set does effect the source context.)
 	Clone() Context
-	Get(string) interface{}
-	Set(string, interface{})
+	// Get returns the value of a variable, and whether it is bound.
+	Get(name string) (interface{}, bool)
+	// Set binds a variable. The binding is visible to later evaluations in
+	// this context, and to any context that shares its binding map.
+	Set(name string, value interface{})
 }
 
 type context struct {
 	Config
 	bindings map[string]interface{}
+	// resolved holds the results of the lazy bindings that have been
+	// referenced. They're kept apart from bindings, which belongs to the caller.
+	resolved map[string]interface{}
 }
 
 // NewContext makes a new expression evaluation context.
 func NewContext(vars map[string]interface{}, cfg Config) Context {
-	return &context{cfg, vars}
+	return &context{cfg, vars, map[string]interface{}{}}
 }
 
 func (c *context) Clone() Context {
@@ -27,15 +37,48 @@ func (c *context) Clone() Context {
 	for k, v := range c.bindings {
 		bindings[k] = v
 	}
-	return &context{c.Config, bindings}
+	resolved := map[string]interface{}{}
+	for k, v := range c.resolved {
+		resolved[k] = v
+	}
+	return &context{c.Config, bindings, resolved}
 }
 
 // Get looks up a variable value in the expression context.
-func (c *context) Get(name string) interface{} {
-	return values.ToLiquid(c.bindings[name])
+//
+// A variable that is bound to a function with no arguments and a single
+// result is lazy (see IsLazy): the function is called the first time the
+// variable is referenced in this context, and its result is used thereafter.
+func (c *context) Get(name string) (interface{}, bool) {
+	value, ok := c.bindings[name]
+	if v, found := c.resolved[name]; found {
+		value = v
+	} else if IsLazy(value) {
+		value = ResolveLazy(value)
+		c.resolved[name] = value
+	}
+	return values.ToLiquid(value), ok
 }
 
 // Set sets a variable value in the expression context.
 func (c *context) Set(name string, value interface{}) {
 	c.bindings[name] = value
+	delete(c.resolved, name)
+}
+
+// IsLazy reports whether a binding is lazy: a function with no arguments and
+// a single result, which supplies the variable's value when it's referenced.
+func IsLazy(value interface{}) bool {
+	rv := reflect.ValueOf(value)
+	return rv.Kind() == reflect.Func && !rv.IsNil() &&
+		rv.Type().NumIn() == 0 && rv.Type().NumOut() == 1
+}
+
+// ResolveLazy returns the value of a binding: the result of calling it, if it
+// is lazy, or else the binding itself.
+func ResolveLazy(value interface{}) interface{} {
+	if !IsLazy(value) {
+		return value
+	}
+	return reflect.ValueOf(value).Call(nil)[0].Interface()
 }
@@ -44,6 +44,42 @@ func (c closure) Evaluate() (interface{}, error) {
 
 type expression struct {
 	evaluator func(Context) values.Value
+	variables []string
+	filters   []string
+}
+
+// Variables returns the names of the variables that an expression refers to,
+// in order of first reference. It returns nil for an expression that wasn't
+// created by Parse.
+func Variables(expr Expression) []string {
+	if e, ok := expr.(*expression); ok {
+		return uniqueStrings(e.variables)
+	}
+	return nil
+}
+
+// Filters returns the names of the filters that an expression applies, in
+// order of first use. It returns nil for an expression that wasn't created by
+// Parse.
+func Filters(expr Expression) []string {
+	if e, ok := expr.(*expression); ok {
+		return uniqueStrings(e.filters)
+	}
+	return nil
+}
+
+func uniqueStrings(names []string) []string {
+	var (
+		result []string
+		seen   = map[string]bool{}
+	)
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
 }
 
 func (e expression) Evaluate(ctx Context) (out interface{}, err error) {
@@ -58,6 +94,8 @@ func (e expression) Evaluate(ctx Context) (out interface{}, err error) {
 				err = e
 			case FilterError:
 				err = e
+			case *FilterArgumentError:
+				err = e
 			case error:
 				panic(&rethrownError{e, debug.Stack()})
 			default:
@@ -84,3 +122,7 @@ func (e *rethrownError) Error() string {
 func (e *rethrownError) Cause() error {
 	return e.cause
 }
+
+func (e *rethrownError) Unwrap() error {
+	return e.cause
+}
@@ -30,7 +30,7 @@ func init() {
 %type<exprs> exprs expr2
 %type<cycle> cycle
 %type<cyclefn> cycle2
-%type<ss> cycle3
+%type<ss> cycle3 idents
 %type<loop> loop
 %type<loopmods> loop_modifiers
 %type<s> string
@@ -43,12 +43,17 @@ func init() {
 %%
 start:
   cond ';' { yylex.(*lexer).val = $1 }
-| ASSIGN IDENTIFIER '=' filtered ';' {
-	yylex.(*lexer).Assignment = Assignment{$2, &expression{$4}}
+| ASSIGN IDENTIFIER idents '=' filtered ';' {
+	yylex.(*lexer).Assignment = Assignment{
+		Variable:  $2,
+		Variables: append([]string{$2}, $3...),
+		ValueFn:   &expression{evaluator: $5},
+	}
 }
 | CYCLE cycle ';' { yylex.(*lexer).Cycle = $2 }
 | LOOP loop ';'   { yylex.(*lexer).Loop = $2 }
-| WHEN exprs ';'  { yylex.(*lexer).When = When{$2} }
+| WHEN exprs ';'  { yylex.(*lexer).When = When{Exprs: $2} }
+| WHEN CONTAINS exprs ';' { yylex.(*lexer).When = When{Exprs: $3, Contains: true} }
 ;
 
 cycle: string cycle2 { $$ = $2($1) };
@@ -69,10 +74,15 @@ cycle3:
 | ',' string cycle3 { $$ = append([]string{$2}, $3...) }
 ;
 
-exprs: expr expr2 { $$ = append([]Expression{&expression{$1}}, $2...) } ;
+idents:
+  /* empty */ { $$ = []string{} }
+| ',' IDENTIFIER idents { $$ = append([]string{$2}, $3...) }
+;
+
+exprs: expr expr2 { $$ = append([]Expression{&expression{evaluator: $1}}, $2...) } ;
 expr2:
   /* empty */    { $$ = []Expression{} }
-| ',' expr expr2 { $$ = append([]Expression{&expression{$2}}, $3...) }
+| ',' expr expr2 { $$ = append([]Expression{&expression{evaluator: $2}}, $3...) }
 ;
 
 string: LITERAL {
@@ -85,7 +95,7 @@ string: LITERAL {
 
 loop: IDENTIFIER IN filtered loop_modifiers {
 	name, expr, mods := $1, $3, $4
-	$$ = Loop{name, &expression{expr}, mods}
+	$$ = Loop{name, &expression{evaluator: expr}, mods}
 }
 ;
 
@@ -102,11 +112,11 @@ loop_modifiers: /* empty */ { $$ = loopModifiers{} }
 | loop_modifiers KEYWORD expr {
     switch $2 {
 	case "cols":
-		$1.Cols = &expression{$3}
+		$1.Cols = &expression{evaluator: $3}
 	case "limit":
-		$1.Limit = &expression{$3}
+		$1.Limit = &expression{evaluator: $3}
 	case "offset":
-		$1.Offset = &expression{$3}
+		$1.Offset = &expression{evaluator: $3}
 	default:
 		panic(SyntaxError(fmt.Sprintf("undefined loop modifier %q", $2)))
 	}
@@ -116,7 +126,21 @@ loop_modifiers: /* empty */ { $$ = loopModifiers{} }
 
 expr:
   LITERAL { val := $1; $$ = func(Context) values.Value { return values.ValueOf(val) } }
-| IDENTIFIER { name := $1; $$ = func(ctx Context) values.Value { return values.ValueOf(ctx.Get(name)) } }
+| IDENTIFIER {
+	name := $1
+	if name != "empty" {
+		// empty is reported as a literal, not a variable
+		yylex.(*lexer).variables = append(yylex.(*lexer).variables, name)
+	}
+	$$ = func(ctx Context) values.Value {
+		value, ok := ctx.Get(name)
+		if !ok && name == "empty" {
+			// an unbound empty is the empty literal
+			value = values.Empty
+		}
+		return values.ValueOf(value)
+	}
+}
 | expr PROPERTY { $$ = makeObjectPropertyExpr($1, $2) }
 | expr '[' expr ']' { $$ = makeIndexExpr($1, $3) }
 | '(' expr DOTDOT expr ')' { $$ = makeRangeExpr($2, $4) }
@@ -125,8 +149,14 @@ expr:
 
 filtered:
   expr
-| filtered '|' IDENTIFIER { $$ = makeFilter($1, $3, nil) }
-| filtered '|' KEYWORD filter_params { $$ = makeFilter($1, $3, $4) }
+| filtered '|' IDENTIFIER {
+	yylex.(*lexer).filters = append(yylex.(*lexer).filters, $3)
+	$$ = makeFilter($1, $3, nil)
+}
+| filtered '|' KEYWORD filter_params {
+	yylex.(*lexer).filters = append(yylex.(*lexer).filters, $3)
+	$$ = makeFilter($1, $3, $4)
+}
 ;
 
 filter_params:
@@ -168,14 +198,14 @@ rel:
 	fa, fb := $1, $3
 	$$ = func(ctx Context) values.Value {
 		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(b.Less(a) || a.Equal(b))
+		return values.ValueOf(values.LessOrEqual(b.Interface(), a.Interface()))
 	}
 }
 | expr LE expr {
 	fa, fb := $1, $3
 	$$ = func(ctx Context) values.Value {
 		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(a.Less(b) || a.Equal(b))
+		return values.ValueOf(values.LessOrEqual(a.Interface(), b.Interface()))
 	}
 }
 | expr CONTAINS expr { $$ = makeContainsExpr($1, $3) }
@@ -46,6 +46,21 @@ var evaluatorTests = []struct {
 	{`hash[1]`, nil},
 	{`hash.c[0]`, "r"},
 
+	// Mixed indices and attributes
+	{`data["users"][0].name`, "Ann"},
+	{`data.users[0]["name"]`, "Ann"},
+	{`data["users"][1]["tags"][0]`, "admin"},
+	{`data.users[-1].tags.first`, "admin"},
+	{`data.users.last["tags"].size`, 2},
+	{`data[key].field`, "value"},
+	{`data[key]["field"].size`, 5},
+	{`hash["b"]["c"]`, "d"},
+	{`data.users[n].name`, nil},
+	{`data.users[5].name`, nil},
+	{`data.missing[0].name`, nil},
+	{`data["users"][0]["missing"].name`, nil},
+	{`data.users[0].name[0]`, nil},
+
 	// Range
 	{`(1..5)`, values.NewRange(1, 5)},
 	{`(1..range.end)`, values.NewRange(1, 5)},
@@ -71,6 +86,17 @@ var evaluatorTests = []struct {
 	{`1.0 != 1.0`, false},
 	{`1 != 1.0`, false},
 	{`1 != 2.0`, true},
+	{`"a" != "a"`, false},
+	{`"a" != "b"`, true},
+
+	// <> is Shopify's alias for !=
+	{`1 <> 1`, false},
+	{`1 <> 2`, true},
+	{`1<>2.0`, true},
+	{`"a" <> "a"`, false},
+	{`"a" <> "b"`, true},
+	{`"<>" <> '<>'`, false},
+	{`n <> 123 or "x<>" == 'x<>'`, true},
 
 	{`1 < 2`, true},
 	{`2 < 1`, false},
@@ -95,6 +121,39 @@ var evaluatorTests = []struct {
 	{`1 >= 1`, true},
 	{`1 >= 2`, false},
 	{`2 >= 1`, true},
+	{`1.5 >= 1`, true},
+	{`"a" >= "a"`, true},
+	{`"a" >= "b"`, false},
+	{`"b" >= "a"`, true},
+	{`1.5 <= 1`, false},
+
+	// a numeric string is compared to a number as a number; other strings
+	// aren't less than, greater than, or equal to a number
+	{`"2" > 1`, true},
+	{`"2" < 1`, false},
+	{`1 < "10"`, true},
+	{`"2" >= 2`, true},
+	{`2 <= "2.0"`, true},
+	{`"abc" > 1`, false},
+	{`"abc" < 1`, false},
+	{`"abc" >= 1`, false},
+	{`"abc" <= 1`, false},
+	{`2 == "2"`, false},
+	{`2 != "2"`, true},
+
+	// empty is equal to empty strings, arrays, and maps, but not to nil
+	{`empty_list == empty`, true},
+	{`empty == empty_list`, true},
+	{`empty_list != empty`, false},
+	{`array == empty`, false},
+	{`array != empty`, true},
+	{`"" == empty`, true},
+	{`"a" == empty`, false},
+	{`empty_hash == empty`, true},
+	{`hash == empty`, false},
+	{`missing == empty`, false},
+	{`false == empty`, false},
+	{`empty == empty`, true},
 
 	{`true and false`, false},
 	{`true and true`, true},
@@ -108,6 +167,9 @@ var evaluatorTests = []struct {
 	{`interface_array contains "first"`, true},
 	{`"foo" contains "missing"`, false},
 	{`nil contains "missing"`, false},
+	{`hash contains "a"`, true},
+	{`hash contains "missing"`, false},
+	{`hash contains 1`, false},
 
 	// filters
 	{`"seafood" | length`, 8},
@@ -118,6 +180,7 @@ var evaluatorTestBindings = (map[string]interface{}{
 	"array":           []string{"first", "second", "third"},
 	"interface_array": []interface{}{"first", "second", "third"},
 	"empty_list":      []interface{}{},
+	"empty_hash":      map[string]interface{}{},
 	"fruits":          []string{"apples", "oranges", "peaches", "plums"},
 	"hash": map[string]interface{}{
 		"a": "first",
@@ -125,6 +188,14 @@ var evaluatorTestBindings = (map[string]interface{}{
 		"c": []string{"r", "g", "b"},
 	},
 	"hash_with_size_key": map[string]interface{}{"size": "key_value"},
+	"data": map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"name": "Ann"},
+			map[string]interface{}{"name": "Bo", "tags": []string{"admin", "editor"}},
+		},
+		"settings": map[string]interface{}{"field": "value"},
+	},
+	"key": "settings",
 	"range": map[string]interface{}{
 		"begin": 1,
 		"end":   5,
@@ -168,3 +239,105 @@ func TestClosure(t *testing.T) {
 	require.Equal(t, 1, x1)
 	require.Equal(t, 2, x2)
 }
+
+func TestEvaluateString_lazyValues(t *testing.T) {
+	calls := 0
+	bindings := map[string]interface{}{
+		"lazy": func() interface{} {
+			calls++
+			return map[string]interface{}{"a": "first"}
+		},
+		"lazy_string": func() string { return "value" },
+		"func":        strings.ToUpper,
+	}
+	ctx := NewContext(bindings, NewConfig())
+
+	// the function isn't called until the variable is referenced
+	_, err := EvaluateString(`lazy_string`, ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, calls)
+
+	// it's called once, however many times the variable is referenced
+	for i := 0; i < 3; i++ {
+		val, err := EvaluateString(`lazy.a`, ctx)
+		require.NoError(t, err)
+		require.Equal(t, "first", val)
+	}
+	require.Equal(t, 1, calls)
+
+	val, err := EvaluateString(`lazy_string`, ctx)
+	require.NoError(t, err)
+	require.Equal(t, "value", val)
+
+	// functions that take arguments are not called
+	val, err = EvaluateString(`func`, ctx)
+	require.NoError(t, err)
+	require.IsType(t, strings.ToUpper, val)
+
+	// the caller's bindings aren't modified
+	require.IsType(t, func() string { return "" }, bindings["lazy_string"])
+}
+
+func TestContext_GetSet(t *testing.T) {
+	bindings := map[string]interface{}{"x": 1, "nil": nil}
+	ctx := NewContext(bindings, NewConfig())
+
+	value, ok := ctx.Get("x")
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+	value, ok = ctx.Get("nil")
+	require.True(t, ok)
+	require.Nil(t, value)
+	_, ok = ctx.Get("missing")
+	require.False(t, ok)
+
+	ctx.Set("y", "set")
+	value, ok = ctx.Get("y")
+	require.True(t, ok)
+	require.Equal(t, "set", value)
+	val, err := EvaluateString(`y == "set"`, ctx)
+	require.NoError(t, err)
+	require.Equal(t, true, val)
+
+	// a clone has its own bindings
+	clone := ctx.Clone()
+	clone.Set("y", "changed")
+	value, _ = ctx.Get("y")
+	require.Equal(t, "set", value)
+}
+
+func TestVariables(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected []string
+	}{
+		{`1`, nil},
+		{`a`, []string{"a"}},
+		{`a.b[c].d`, []string{"a", "c"}},
+		{`a | append: b | upcase`, []string{"a", "b"}},
+		{`a == b or a contains c`, []string{"a", "b", "c"}},
+		{`(a..b)`, []string{"a", "b"}},
+		{`"a" | default: nil`, nil},
+		{`a == empty`, []string{"a"}},
+	}
+	for _, test := range tests {
+		expr, err := Parse(test.in)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, Variables(expr), test.in)
+	}
+	require.Nil(t, Variables(Not(&expression{})))
+}
+
+func TestFilters(t *testing.T) {
+	expr, err := Parse(`a | append: b | upcase | append: "c"`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"append", "upcase"}, Filters(expr))
+
+	expr, err = Parse(`a.b`)
+	require.NoError(t, err)
+	require.Nil(t, Filters(expr))
+
+	stmt, err := ParseStatement(LoopStatementSelector, "x in xs | sort limit: n")
+	require.NoError(t, err)
+	require.Equal(t, []string{"sort"}, stmt.Filters())
+}
@@ -1,8 +1,10 @@
 package expressions
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 
 	"github.com/osteele/liquid/values"
 )
@@ -14,13 +16,20 @@ type InterpreterError string
 
 func (e InterpreterError) Error() string { return string(e) }
 
+// ErrUndefinedFilter is the error that an UndefinedFilter wraps, for use with
+// errors.Is.
+var ErrUndefinedFilter = errors.New("undefined filter")
+
 // UndefinedFilter is an error that the named filter is not defined.
 type UndefinedFilter string
 
 func (e UndefinedFilter) Error() string {
-	return fmt.Sprintf("undefined filter %q", string(e))
+	return fmt.Sprintf("%s %q", ErrUndefinedFilter, string(e))
 }
 
+// Unwrap returns ErrUndefinedFilter.
+func (e UndefinedFilter) Unwrap() error { return ErrUndefinedFilter }
+
 // FilterError is the error returned by a filter when it is applied
 type FilterError struct {
 	FilterName string
@@ -31,25 +40,120 @@ func (e FilterError) Error() string {
 	return fmt.Sprintf("error applying filter %q (%q)", e.FilterName, e.Err)
 }
 
+// Unwrap returns the error that the filter returned.
+func (e FilterError) Unwrap() error { return e.Err }
+
+// A FilterArgumentError is a failure to convert a filter's input or one of its
+// arguments to the type that the filter requires.
+type FilterArgumentError struct {
+	Filter string // the filter name
+	Arg    int    // 0 for the input, 1 for the first argument, and so on
+	Err    error
+}
+
+func (e *FilterArgumentError) Error() string {
+	if e.Arg == 0 {
+		return fmt.Sprintf("invalid input to filter %q: %s", e.Filter, e.Err)
+	}
+	return fmt.Sprintf("invalid argument %d to filter %q: %s", e.Arg, e.Filter, e.Err)
+}
+
+// Unwrap returns the conversion error.
+func (e *FilterArgumentError) Unwrap() error { return e.Err }
+
 type valueFn func(Context) values.Value
 
-// AddFilter adds a filter to the filter dictionary.
+// A FilterContext gives a filter access to the variables of the template that
+// applies it, for example to read a locale or settings. A filter whose first
+// parameter has this type receives the context there, and its input as its
+// second parameter.
+type FilterContext interface {
+	// Get returns the value of a variable, and whether it is bound.
+	Get(name string) (interface{}, bool)
+}
+
+var (
+	filterContextType = reflect.TypeOf((*FilterContext)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// isContextFilter returns true if the filter function takes a FilterContext.
+func isContextFilter(ft reflect.Type) bool {
+	return ft.NumIn() > 0 && ft.In(0) == filterContextType
+}
+
+// AddFilter adds a filter to the filter dictionary. It panics if fn isn't a
+// valid filter function.
 func (c *Config) AddFilter(name string, fn interface{}) {
+	if err := c.AddFilterErr(name, fn); err != nil {
+		panic(err)
+	}
+}
+
+// AddFilterErr is like AddFilter, but it returns an error that names the
+// filter and describes the problem, instead of panicking, if fn isn't a valid
+// filter function. Use it for filters that a host's users supply.
+func (c *Config) AddFilterErr(name string, fn interface{}) error {
+	if err := checkFilter(fn); err != nil {
+		return fmt.Errorf("filter %q: %s", name, err)
+	}
+	if len(c.filters) == 0 {
+		c.filters = make(map[string]interface{})
+	}
+	c.filters[name] = fn
+	return nil
+}
+
+// AddFilters adds several filters to the filter dictionary. It checks them all
+// first, and panics without adding any if one isn't a valid filter function.
+func (c *Config) AddFilters(filters map[string]interface{}) {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := checkFilter(filters[name]); err != nil {
+			panic(fmt.Errorf("filter %q: %s", name, err))
+		}
+	}
+	for _, name := range names {
+		c.AddFilter(name, filters[name])
+	}
+}
+
+// checkFilter returns an error if fn can't be used as a filter.
+func checkFilter(fn interface{}) error {
 	rf := reflect.ValueOf(fn)
 	switch {
 	case rf.Kind() != reflect.Func:
-		panic(fmt.Errorf("a filter must be a function"))
+		return fmt.Errorf("a filter must be a function; got %T", fn)
 	case rf.Type().NumIn() < 1:
-		panic(fmt.Errorf("a filter function must have at least one input"))
+		return fmt.Errorf("a filter function must have at least one input")
+	case isContextFilter(rf.Type()) && rf.Type().NumIn() < 2:
+		return fmt.Errorf("a filter function must have an input after its FilterContext")
 	case rf.Type().NumOut() < 1 || 2 < rf.Type().NumOut():
-		panic(fmt.Errorf("a filter must be have one or two outputs"))
-		// case rf.Type().Out(1).Implements(…):
-		// 	panic(typeError("a filter's second output must be type error"))
+		return fmt.Errorf("a filter must have one or two outputs; %s has %d", rf.Type(), rf.Type().NumOut())
+	case rf.Type().NumOut() == 2 && !rf.Type().Out(1).Implements(errorType):
+		return fmt.Errorf("a filter's second output must be type error; got %s", rf.Type().Out(1))
 	}
-	if len(c.filters) == 0 {
-		c.filters = make(map[string]interface{})
+	return nil
+}
+
+// FilterNames returns the sorted names of the defined filters.
+func (c *Config) FilterNames() []string {
+	names := make([]string, 0, len(c.filters))
+	for name := range c.filters {
+		names = append(names, name)
 	}
-	c.filters[name] = fn
+	sort.Strings(names)
+	return names
+}
+
+// HasFilter returns true if the named filter is defined.
+func (c *Config) HasFilter(name string) bool {
+	_, ok := c.filters[name]
+	return ok
 }
 
 var closureType = reflect.TypeOf(closure{})
@@ -65,9 +169,16 @@ func (ctx *context) ApplyFilter(name string, receiver valueFn, params []valueFn)
 		panic(UndefinedFilter(name))
 	}
 	fr := reflect.ValueOf(filter)
-	args := []interface{}{receiver(ctx).Interface()}
+	// skip is the number of parameters before the filter's input
+	skip := 0
+	args := []interface{}{}
+	if isContextFilter(fr.Type()) {
+		skip = 1
+		args = append(args, FilterContext(ctx))
+	}
+	args = append(args, receiver(ctx).Interface())
 	for i, param := range params {
-		if i+1 < fr.Type().NumIn() && isClosureInterfaceType(fr.Type().In(i+1)) {
+		if n := i + skip + 1; n < fr.Type().NumIn() && isClosureInterfaceType(fr.Type().In(n)) {
 			expr, err := Parse(param(ctx).Interface().(string))
 			if err != nil {
 				panic(err)
@@ -79,8 +190,11 @@ func (ctx *context) ApplyFilter(name string, receiver valueFn, params []valueFn)
 	}
 	out, err := values.Call(fr, args)
 	if err != nil {
-		if e, ok := err.(*values.CallParityError); ok {
-			err = &values.CallParityError{NumArgs: e.NumArgs - 1, NumParams: e.NumParams - 1}
+		switch e := err.(type) {
+		case *values.CallParityError:
+			err = &values.CallParityError{NumArgs: e.NumArgs - skip - 1, NumParams: e.NumParams - skip - 1}
+		case *values.CallArgumentError:
+			err = &FilterArgumentError{Filter: name, Arg: e.Index - skip, Err: e.Err}
 		}
 		return nil, err
 	}
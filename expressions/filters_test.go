@@ -1,6 +1,7 @@
 package expressions
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -14,11 +15,76 @@ func TestContext_AddFilter(t *testing.T) {
 	require.NotPanics(t, func() { cfg.AddFilter("f", func(int) (a int, e error) { return }) })
 	require.Panics(t, func() { cfg.AddFilter("f", func() int { return 0 }) })
 	require.Panics(t, func() { cfg.AddFilter("f", func(int) {}) })
-	// require.Panics(t, func() { cfg.AddFilter("f", func(int) (a int, b int) { return }) })
+	require.Panics(t, func() { cfg.AddFilter("f", func(int) (a int, b int) { return }) })
 	require.Panics(t, func() { cfg.AddFilter("f", func(int) (a int, e error, b int) { return }) })
 	require.Panics(t, func() { cfg.AddFilter("f", 10) })
 }
 
+func TestConfig_AddFilterErr(t *testing.T) {
+	cfg := NewConfig()
+	require.NoError(t, cfg.AddFilterErr("double", func(n int) int { return 2 * n }))
+	require.NoError(t, cfg.AddFilterErr("check", func(n int) (int, error) { return n, nil }))
+	ctx := NewContext(map[string]interface{}{}, cfg)
+	value, err := EvaluateString(`3 | double | check`, ctx)
+	require.NoError(t, err)
+	require.Equal(t, 6, value)
+
+	tests := []struct {
+		fn       interface{}
+		expected string
+	}{
+		{"upcase", `filter "bad": a filter must be a function; got string`},
+		{nil, `filter "bad": a filter must be a function; got <nil>`},
+		{func() int { return 0 }, `filter "bad": a filter function must have at least one input`},
+		{func(int) {}, `filter "bad": a filter must have one or two outputs; func(int) has 0`},
+		{func(int) (a, b, c int) { return }, `filter "bad": a filter must have one or two outputs; func(int) (int, int, int) has 3`},
+		{func(int) (a, b int) { return }, `filter "bad": a filter's second output must be type error; got int`},
+		{func(FilterContext) int { return 0 }, `filter "bad": a filter function must have an input after its FilterContext`},
+	}
+	for _, test := range tests {
+		err := cfg.AddFilterErr("bad", test.fn)
+		require.EqualError(t, err, test.expected)
+	}
+	require.Equal(t, []string{"check", "double"}, cfg.FilterNames())
+}
+
+func TestConfig_AddFilters(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AddFilters(map[string]interface{}{
+		"double": func(n int) int { return 2 * n },
+		"inc":    func(n int) int { return n + 1 },
+	})
+	ctx := NewContext(map[string]interface{}{}, cfg)
+	value, err := EvaluateString(`3 | double | inc`, ctx)
+	require.NoError(t, err)
+	require.Equal(t, 7, value)
+
+	// an invalid function is reported by name, and nothing is added
+	cfg = NewConfig()
+	require.PanicsWithError(t, `filter "bad": a filter must be a function; got int`, func() {
+		cfg.AddFilters(map[string]interface{}{
+			"good": func(n int) int { return n },
+			"bad":  10,
+		})
+	})
+	require.Empty(t, cfg.FilterNames())
+	require.PanicsWithError(t, `filter "none": a filter must have one or two outputs; func(int) has 0`, func() {
+		cfg.AddFilters(map[string]interface{}{"none": func(int) {}})
+	})
+}
+
+func TestConfig_FilterNames(t *testing.T) {
+	cfg := NewConfig()
+	require.Empty(t, cfg.FilterNames())
+	require.False(t, cfg.HasFilter("f"))
+
+	cfg.AddFilter("g", func(int) int { return 0 })
+	cfg.AddFilter("f", func(int) int { return 0 })
+	require.Equal(t, []string{"f", "g"}, cfg.FilterNames())
+	require.True(t, cfg.HasFilter("f"))
+	require.False(t, cfg.HasFilter("h"))
+}
+
 func TestContext_runFilter(t *testing.T) {
 	cfg := NewConfig()
 	constant := func(value interface{}) valueFn {
@@ -70,3 +136,105 @@ func TestContext_runFilter(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "(self, 11)", out)
 }
+
+func TestContext_filterArgumentError(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AddFilter("add", func(a, b int) int { return a + b })
+	ctx := NewContext(map[string]interface{}{}, cfg)
+
+	_, err := EvaluateString(`1 | add: "two"`, ctx)
+	require.Error(t, err)
+	var fe *FilterArgumentError
+	require.True(t, errors.As(err, &fe))
+	require.Equal(t, "add", fe.Filter)
+	require.Equal(t, 1, fe.Arg)
+	require.Contains(t, err.Error(), `invalid argument 1 to filter "add"`)
+
+	_, err = EvaluateString(`"one" | add: 2`, ctx)
+	require.True(t, errors.As(err, &fe))
+	require.Equal(t, 0, fe.Arg)
+	require.Contains(t, err.Error(), `invalid input to filter "add"`)
+}
+
+func TestContext_variadicFilter(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AddFilter("args", func(s string, args ...interface{}) string {
+		return fmt.Sprint(s, args)
+	})
+	cfg.AddFilter("sum", func(n int, args ...int) int {
+		for _, m := range args {
+			n += m
+		}
+		return n
+	})
+	ctx := NewContext(map[string]interface{}{"x": 10}, cfg)
+	tests := []struct {
+		in       string
+		expected interface{}
+	}{
+		{`"a" | args`, "a[]"},
+		{`"a" | args: 1`, "a[1]"},
+		{`"a" | args: 1, "b", x, nil, 2.5`, "a[1 b 10 <nil> 2.5]"},
+		{`"a" | args: 1 | args: 2, 3`, "a[1][2 3]"},
+		{`1 | sum`, 1},
+		{`1 | sum: 2`, 3},
+		{`1 | sum: 2, "3", x, 4.0`, 20},
+	}
+	for _, test := range tests {
+		value, err := EvaluateString(test.in, ctx)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, value, test.in)
+	}
+
+	// a trailing argument that can't be converted is reported by position
+	_, err := EvaluateString(`1 | sum: 2, "three"`, ctx)
+	var fe *FilterArgumentError
+	require.True(t, errors.As(err, &fe))
+	require.Equal(t, 2, fe.Arg)
+}
+
+func TestContext_contextFilter(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AddFilter("greet", func(ctx FilterContext, name string, greeting func(string) string) string {
+		locale, _ := ctx.Get("locale")
+		if locale == "fr" {
+			return greeting("Bonjour") + " " + name
+		}
+		return greeting("Hello") + " " + name
+	})
+	cfg.AddFilter("bound", func(ctx FilterContext, name string) bool {
+		_, ok := ctx.Get(name)
+		return ok
+	})
+	require.Panics(t, func() { cfg.AddFilter("f", func(FilterContext) int { return 0 }) })
+
+	ctx := NewContext(map[string]interface{}{"locale": "fr", "n": 1}, cfg)
+	tests := []struct {
+		in       string
+		expected interface{}
+	}{
+		{`"Ann" | greet`, "Bonjour Ann"},
+		{`"Ann" | greet: "Salut"`, "Salut Ann"},
+		{`"n" | bound`, true},
+		{`"m" | bound`, false},
+	}
+	for _, test := range tests {
+		value, err := EvaluateString(test.in, ctx)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, value, test.in)
+	}
+
+	value, err := EvaluateString(`"Ann" | greet`, NewContext(map[string]interface{}{}, cfg))
+	require.NoError(t, err)
+	require.Equal(t, "Hello Ann", value)
+
+	// argument errors are reported by the position in the template
+	cfg.AddFilter("add", func(ctx FilterContext, a, b int) int { return a + b })
+	_, err = EvaluateString(`1 | add: "two"`, ctx)
+	var fe *FilterArgumentError
+	require.True(t, errors.As(err, &fe))
+	require.Equal(t, 1, fe.Arg)
+	_, err = EvaluateString(`1 | add: 2, 3`, ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "given 2, expected 1")
+}
@@ -16,6 +16,11 @@ type parseValue struct {
 	Loop
 	When
 	val func(Context) values.Value
+	// variables are the variables that the source refers to, in order of
+	// reference; they may repeat.
+	variables []string
+	// filters are the names of the filters that the source applies.
+	filters []string
 }
 
 // SyntaxError represents a syntax error. The yacc-generated compiler
@@ -30,7 +35,7 @@ func Parse(source string) (expr Expression, err error) {
 	if err != nil {
 		return nil, err
 	}
-	return &expression{p.val}, nil
+	return &expression{p.val, p.variables, p.filters}, nil
 }
 
 func parse(source string) (p *parseValue, err error) {
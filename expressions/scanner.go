@@ -21,10 +21,10 @@ var _expression_key_offsets []int16 = []int16{
 	8, 9, 10, 11, 12, 14, 16, 17,
 	18, 19, 20, 21, 22, 23, 24, 25,
 	52, 55, 56, 57, 59, 60, 62, 65,
-	67, 73, 82, 83, 84, 85, 95, 96,
-	107, 118, 129, 140, 151, 162, 173, 184,
-	195, 206, 217, 228, 239, 250, 261, 272,
-	283, 294, 305,
+	67, 73, 82, 84, 85, 86, 96, 97,
+	108, 119, 130, 141, 152, 163, 174, 185,
+	196, 207, 218, 229, 240, 251, 262, 273,
+	284, 295, 306,
 }
 
 var _expression_trans_keys []byte = []byte{
@@ -38,35 +38,35 @@ var _expression_trans_keys []byte = []byte{
 	34, 97, 108, 39, 48, 57, 46, 48,
 	57, 48, 57, 46, 95, 65, 90, 97,
 	122, 45, 63, 95, 48, 57, 65, 90,
-	97, 122, 61, 61, 61, 45, 58, 63,
-	95, 48, 57, 65, 90, 97, 122, 58,
-	45, 58, 63, 95, 110, 48, 57, 65,
-	90, 97, 122, 45, 58, 63, 95, 100,
-	48, 57, 65, 90, 97, 122, 45, 58,
-	63, 95, 111, 48, 57, 65, 90, 97,
-	122, 45, 58, 63, 95, 110, 48, 57,
+	97, 122, 61, 62, 61, 61, 45, 58,
+	63, 95, 48, 57, 65, 90, 97, 122,
+	58, 45, 58, 63, 95, 110, 48, 57,
 	65, 90, 97, 122, 45, 58, 63, 95,
-	116, 48, 57, 65, 90, 97, 122, 45,
-	58, 63, 95, 97, 48, 57, 65, 90,
-	98, 122, 45, 58, 63, 95, 105, 48,
+	100, 48, 57, 65, 90, 97, 122, 45,
+	58, 63, 95, 111, 48, 57, 65, 90,
+	97, 122, 45, 58, 63, 95, 110, 48,
 	57, 65, 90, 97, 122, 45, 58, 63,
-	95, 110, 48, 57, 65, 90, 97, 122,
-	45, 58, 63, 95, 115, 48, 57, 65,
-	90, 97, 122, 45, 58, 63, 95, 97,
-	48, 57, 65, 90, 98, 122, 45, 58,
-	63, 95, 108, 48, 57, 65, 90, 97,
+	95, 116, 48, 57, 65, 90, 97, 122,
+	45, 58, 63, 95, 97, 48, 57, 65,
+	90, 98, 122, 45, 58, 63, 95, 105,
+	48, 57, 65, 90, 97, 122, 45, 58,
+	63, 95, 110, 48, 57, 65, 90, 97,
 	122, 45, 58, 63, 95, 115, 48, 57,
 	65, 90, 97, 122, 45, 58, 63, 95,
-	101, 48, 57, 65, 90, 97, 122, 45,
-	58, 63, 95, 110, 48, 57, 65, 90,
-	97, 122, 45, 58, 63, 95, 105, 48,
+	97, 48, 57, 65, 90, 98, 122, 45,
+	58, 63, 95, 108, 48, 57, 65, 90,
+	97, 122, 45, 58, 63, 95, 115, 48,
 	57, 65, 90, 97, 122, 45, 58, 63,
-	95, 108, 48, 57, 65, 90, 97, 122,
-	45, 58, 63, 95, 114, 48, 57, 65,
-	90, 97, 122, 45, 58, 63, 95, 114,
+	95, 101, 48, 57, 65, 90, 97, 122,
+	45, 58, 63, 95, 110, 48, 57, 65,
+	90, 97, 122, 45, 58, 63, 95, 105,
 	48, 57, 65, 90, 97, 122, 45, 58,
-	63, 95, 117, 48, 57, 65, 90, 97,
-	122, 37,
+	63, 95, 108, 48, 57, 65, 90, 97,
+	122, 45, 58, 63, 95, 114, 48, 57,
+	65, 90, 97, 122, 45, 58, 63, 95,
+	114, 48, 57, 65, 90, 97, 122, 45,
+	58, 63, 95, 117, 48, 57, 65, 90,
+	97, 122, 37,
 }
 
 var _expression_single_lengths []byte = []byte{
@@ -74,7 +74,7 @@ var _expression_single_lengths []byte = []byte{
 	1, 1, 1, 1, 0, 2, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 19,
 	1, 1, 1, 2, 1, 0, 1, 0,
-	2, 3, 1, 1, 1, 4, 1, 5,
+	2, 3, 2, 1, 1, 4, 1, 5,
 	5, 5, 5, 5, 5, 5, 5, 5,
 	5, 5, 5, 5, 5, 5, 5, 5,
 	5, 5, 1,
@@ -96,10 +96,10 @@ var _expression_index_offsets []int16 = []int16{
 	16, 18, 20, 22, 24, 26, 29, 31,
 	33, 35, 37, 39, 41, 43, 45, 47,
 	71, 74, 76, 78, 81, 83, 85, 88,
-	90, 95, 102, 104, 106, 108, 116, 118,
-	127, 136, 145, 154, 163, 172, 181, 190,
-	199, 208, 217, 226, 235, 244, 253, 262,
-	271, 280, 289,
+	90, 95, 102, 105, 107, 109, 117, 119,
+	128, 137, 146, 155, 164, 173, 182, 191,
+	200, 209, 218, 227, 236, 245, 254, 263,
+	272, 281, 290,
 }
 
 var _expression_indicies []byte = []byte{
@@ -115,31 +115,31 @@ var _expression_indicies []byte = []byte{
 	28, 48, 50, 49, 2, 1, 51, 52,
 	49, 2, 13, 35, 49, 54, 35, 53,
 	15, 55, 56, 57, 57, 57, 49, 57,
-	59, 57, 57, 57, 57, 58, 60, 49,
-	61, 49, 62, 49, 39, 64, 65, 39,
-	39, 39, 39, 63, 64, 66, 39, 64,
-	65, 39, 67, 39, 39, 39, 66, 39,
-	64, 65, 39, 68, 39, 39, 39, 66,
-	39, 64, 65, 39, 69, 39, 39, 39,
-	66, 39, 64, 65, 39, 70, 39, 39,
-	39, 66, 39, 64, 65, 39, 71, 39,
-	39, 39, 66, 39, 64, 65, 39, 72,
+	59, 57, 57, 57, 57, 58, 60, 50,
+	49, 61, 49, 62, 49, 39, 64, 65,
+	39, 39, 39, 39, 63, 64, 66, 39,
+	64, 65, 39, 67, 39, 39, 39, 66,
+	39, 64, 65, 39, 68, 39, 39, 39,
+	66, 39, 64, 65, 39, 69, 39, 39,
+	39, 66, 39, 64, 65, 39, 70, 39,
+	39, 39, 66, 39, 64, 65, 39, 71,
 	39, 39, 39, 66, 39, 64, 65, 39,
-	73, 39, 39, 39, 66, 39, 64, 65,
-	39, 74, 39, 39, 39, 66, 39, 64,
-	65, 39, 75, 39, 39, 39, 66, 39,
-	64, 65, 39, 76, 39, 39, 39, 66,
-	39, 64, 65, 39, 77, 39, 39, 39,
-	66, 39, 64, 65, 39, 78, 39, 39,
-	39, 66, 39, 64, 65, 39, 79, 39,
-	39, 39, 66, 39, 64, 65, 39, 80,
+	72, 39, 39, 39, 66, 39, 64, 65,
+	39, 73, 39, 39, 39, 66, 39, 64,
+	65, 39, 74, 39, 39, 39, 66, 39,
+	64, 65, 39, 75, 39, 39, 39, 66,
+	39, 64, 65, 39, 76, 39, 39, 39,
+	66, 39, 64, 65, 39, 77, 39, 39,
+	39, 66, 39, 64, 65, 39, 78, 39,
+	39, 39, 66, 39, 64, 65, 39, 79,
 	39, 39, 39, 66, 39, 64, 65, 39,
-	81, 39, 39, 39, 66, 39, 64, 65,
-	39, 82, 39, 39, 39, 66, 39, 64,
-	65, 39, 83, 39, 39, 39, 66, 39,
-	64, 65, 39, 84, 39, 39, 39, 66,
-	39, 64, 65, 39, 78, 39, 39, 39,
-	66, 85, 49,
+	80, 39, 39, 39, 66, 39, 64, 65,
+	39, 81, 39, 39, 39, 66, 39, 64,
+	65, 39, 82, 39, 39, 39, 66, 39,
+	64, 65, 39, 83, 39, 39, 39, 66,
+	39, 64, 65, 39, 84, 39, 39, 39,
+	66, 39, 64, 65, 39, 78, 39, 39,
+	39, 66, 85, 49,
 }
 
 var _expression_trans_targs []byte = []byte{
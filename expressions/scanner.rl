@@ -96,7 +96,7 @@ func (lex *lexer) Lex(out *yySymType) int {
 
 			# relations
 			"==" => { tok = EQ; fbreak; };
-			"!=" => { tok = NEQ; fbreak; };
+			("!=" | "<>") => { tok = NEQ; fbreak; };
 			">=" => { tok = GE; fbreak; };
 			"<=" => { tok = LE; fbreak; };
 			"and" => { tok = AND; fbreak; };
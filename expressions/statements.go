@@ -11,13 +11,29 @@ const (
 // A Statement is the result of parsing a string.
 type Statement struct{ parseValue }
 
+// Variables returns the names of the variables that a statement refers to, in
+// order of first reference. This doesn't include the variables that the
+// statement binds, such as the variable of an assignment or a loop.
+func (s *Statement) Variables() []string {
+	return uniqueStrings(s.variables)
+}
+
+// Filters returns the names of the filters that a statement applies, in order
+// of first use.
+func (s *Statement) Filters() []string {
+	return uniqueStrings(s.filters)
+}
+
 // Expression returns a statement's expression function.
 // func (s *Statement) Expression() Expression { return &expression{s.val} }
 
 // An Assignment is a parse of an {% assign %} statement
 type Assignment struct {
 	Variable string
-	ValueFn  Expression
+	// Variables lists the targets of a destructuring assignment such as
+	// {% assign a, b = pair %}. Its first element is Variable.
+	Variables []string
+	ValueFn   Expression
 }
 
 // A Cycle is a parse of an {% assign %} statement
@@ -43,6 +59,9 @@ type loopModifiers struct {
 // A When is a parse of a {% when %} clause
 type When struct {
 	Exprs []Expression
+	// Contains is set for {% when contains a, b %}, which matches a case value
+	// that contains any of the expressions.
+	Contains bool
 }
 
 // ParseStatement parses an statement into an Expression that can evaluated to return a
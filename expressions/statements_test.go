@@ -11,6 +11,13 @@ func TestParseStatement(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "a", stmt.Assignment.Variable)
 	require.Implements(t, (*Expression)(nil), stmt.Assignment.ValueFn)
+	require.Equal(t, []string{"a"}, stmt.Assignment.Variables)
+
+	stmt, err = ParseStatement(AssignStatementSelector, "a, b = c")
+	require.NoError(t, err)
+	require.Equal(t, "a", stmt.Assignment.Variable)
+	require.Equal(t, []string{"a", "b"}, stmt.Assignment.Variables)
+	require.Equal(t, []string{"c"}, stmt.Variables())
 
 	stmt, err = ParseStatement(CycleStatementSelector, "'a', 'b'")
 	require.NoError(t, err)
@@ -28,6 +35,7 @@ func TestParseStatement(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "x", stmt.Loop.Variable)
 	require.True(t, stmt.Loop.Reversed)
+	require.Equal(t, []string{"array"}, stmt.Variables())
 
 	require.Nil(t, stmt.Loop.Cols)
 	require.NotNil(t, stmt.Loop.Limit)
@@ -38,4 +46,10 @@ func TestParseStatement(t *testing.T) {
 	stmt, err = ParseStatement(WhenStatementSelector, "a, b")
 	require.NoError(t, err)
 	require.Len(t, stmt.When.Exprs, 2)
+	require.False(t, stmt.When.Contains)
+
+	stmt, err = ParseStatement(WhenStatementSelector, "contains a, b")
+	require.NoError(t, err)
+	require.Len(t, stmt.When.Exprs, 2)
+	require.True(t, stmt.When.Contains)
 }
@@ -101,77 +101,78 @@ var yyExca = [...]int8{
 
 const yyPrivate = 57344
 
-const yyLast = 106
+const yyLast = 117
 
 var yyAct = [...]int8{
-	9, 47, 42, 18, 8, 14, 15, 23, 10, 11,
-	10, 11, 76, 34, 3, 4, 5, 6, 25, 25,
-	60, 41, 43, 43, 38, 46, 44, 51, 52, 53,
-	54, 55, 56, 57, 58, 12, 25, 12, 39, 62,
-	26, 26, 24, 61, 77, 63, 62, 64, 45, 66,
-	65, 68, 24, 14, 15, 71, 7, 25, 26, 69,
-	70, 13, 27, 28, 31, 32, 72, 73, 75, 33,
-	59, 36, 37, 30, 29, 25, 25, 80, 2, 26,
-	81, 27, 28, 31, 32, 78, 79, 21, 33, 49,
-	50, 35, 30, 29, 16, 19, 48, 26, 26, 1,
-	74, 20, 40, 17, 22, 67,
+	9, 50, 44, 8, 26, 39, 82, 24, 45, 18,
+	10, 11, 40, 35, 3, 4, 5, 6, 26, 10,
+	11, 14, 15, 26, 24, 26, 27, 74, 54, 55,
+	56, 57, 58, 59, 60, 61, 63, 12, 64, 25,
+	27, 43, 45, 83, 51, 27, 12, 27, 69, 10,
+	11, 68, 71, 66, 73, 67, 70, 25, 14, 15,
+	84, 48, 46, 75, 23, 69, 13, 41, 76, 78,
+	79, 77, 26, 81, 47, 22, 12, 28, 29, 32,
+	33, 85, 86, 87, 34, 62, 65, 88, 31, 30,
+	26, 2, 21, 7, 27, 28, 29, 32, 33, 49,
+	52, 53, 34, 16, 36, 19, 31, 30, 37, 38,
+	1, 80, 27, 20, 42, 17, 72,
 }
 
 var yyPact = [...]int16{
-	6, -1000, 36, 89, 91, 82, 4, -1000, 20, 69,
-	-1000, -1000, 4, -1000, 4, 4, -2, 13, -6, -1000,
-	1, 32, 0, 68, 84, -1000, 4, 4, 4, 4,
-	4, 4, 4, 4, 50, -12, -1000, -1000, 4, -1000,
-	-1000, 91, -1000, 91, -1000, 4, -1000, -1000, 4, -1000,
-	4, 29, 11, 11, 11, 11, 11, 11, 11, 4,
-	-1000, 30, 11, -5, -5, 20, 68, -16, 11, -1000,
-	12, -1000, -1000, -1000, 80, -1000, 4, -1000, -1000, 4,
-	11, 11,
+	6, -1000, 41, 98, 101, 87, 45, -1000, 17, 83,
+	-1000, -1000, 15, -1000, 15, 15, -16, 42, 14, -1000,
+	37, 58, 36, 15, 16, 95, -1000, 15, 15, 15,
+	15, 15, 15, 15, 15, 65, 4, -1000, -1000, 12,
+	81, -1000, -1000, 101, -1000, 101, -1000, 15, -1000, 31,
+	-1000, 15, -1000, 15, -3, 18, 18, 18, 18, 18,
+	18, 18, 15, -1000, 15, -16, -20, -20, 17, 18,
+	-1000, 16, -22, 18, -1000, 11, 35, -1000, -1000, -1000,
+	76, -1000, 15, -1000, -1000, -1000, 15, 18, 18,
 }
 
 var yyPgo = [...]int8{
-	0, 0, 56, 4, 78, 105, 104, 1, 103, 102,
-	2, 101, 100, 3, 99,
+	0, 0, 93, 3, 91, 116, 75, 1, 115, 114,
+	2, 5, 113, 111, 9, 110,
 }
 
 var yyR1 = [...]int8{
-	0, 14, 14, 14, 14, 14, 8, 9, 9, 10,
-	10, 6, 7, 7, 13, 11, 12, 12, 12, 1,
-	1, 1, 1, 1, 1, 3, 3, 3, 5, 5,
-	2, 2, 2, 2, 2, 2, 2, 2, 4, 4,
-	4,
+	0, 15, 15, 15, 15, 15, 15, 8, 9, 9,
+	10, 10, 11, 11, 6, 7, 7, 14, 12, 13,
+	13, 13, 1, 1, 1, 1, 1, 1, 3, 3,
+	3, 5, 5, 2, 2, 2, 2, 2, 2, 2,
+	2, 4, 4, 4,
 }
 
 var yyR2 = [...]int8{
-	0, 2, 5, 3, 3, 3, 2, 3, 1, 0,
-	3, 2, 0, 3, 1, 4, 0, 2, 3, 1,
-	1, 2, 4, 5, 3, 1, 3, 4, 1, 3,
-	1, 3, 3, 3, 3, 3, 3, 3, 1, 3,
-	3,
+	0, 2, 6, 3, 3, 3, 4, 2, 3, 1,
+	0, 3, 0, 3, 2, 0, 3, 1, 4, 0,
+	2, 3, 1, 1, 2, 4, 5, 3, 1, 3,
+	4, 1, 3, 1, 3, 3, 3, 3, 3, 3,
+	3, 1, 3, 3,
 }
 
 var yyChk = [...]int16{
-	-1000, -14, -4, 8, 9, 10, 11, -2, -3, -1,
-	4, 5, 31, 25, 17, 18, 5, -8, -13, 4,
-	-11, 5, -6, -1, 22, 7, 29, 12, 13, 24,
-	23, 14, 15, 19, -1, -4, -2, -2, 26, 25,
-	-9, 27, -10, 28, 25, 16, 25, -7, 28, 5,
-	6, -1, -1, -1, -1, -1, -1, -1, -1, 20,
-	32, -3, -1, -13, -13, -3, -1, -5, -1, 30,
-	-1, 25, -10, -10, -12, -7, 28, 32, 5, 6,
-	-1, -1,
+	-1000, -15, -4, 8, 9, 10, 11, -2, -3, -1,
+	4, 5, 31, 25, 17, 18, 5, -8, -14, 4,
+	-12, 5, -6, 19, -1, 22, 7, 29, 12, 13,
+	24, 23, 14, 15, 19, -1, -4, -2, -2, -11,
+	28, 25, -9, 27, -10, 28, 25, 16, 25, -6,
+	-7, 28, 5, 6, -1, -1, -1, -1, -1, -1,
+	-1, -1, 20, 32, 26, 5, -14, -14, -3, -1,
+	25, -1, -5, -1, 30, -1, -3, -11, -10, -10,
+	-13, -7, 28, 32, 25, 5, 6, -1, -1,
 }
 
 var yyDef = [...]int8{
-	0, -2, 0, 0, 0, 0, 0, 38, 30, 25,
-	19, 20, 0, 1, 0, 0, 0, 0, 9, 14,
-	0, 0, 0, 12, 0, 21, 0, 0, 0, 0,
-	0, 0, 0, 0, 25, 0, 39, 40, 0, 3,
-	6, 0, 8, 0, 4, 0, 5, 11, 0, 26,
-	0, 0, 31, 32, 33, 34, 35, 36, 37, 0,
-	24, 0, 25, 9, 9, 16, 12, 27, 28, 22,
-	0, 2, 7, 10, 15, 13, 0, 23, 17, 0,
-	29, 18,
+	0, -2, 0, 0, 0, 0, 0, 41, 33, 28,
+	22, 23, 0, 1, 0, 0, 12, 0, 10, 17,
+	0, 0, 0, 0, 15, 0, 24, 0, 0, 0,
+	0, 0, 0, 0, 0, 28, 0, 42, 43, 0,
+	0, 3, 7, 0, 9, 0, 4, 0, 5, 0,
+	14, 0, 29, 0, 0, 34, 35, 36, 37, 38,
+	39, 40, 0, 27, 0, 12, 10, 10, 19, 28,
+	6, 15, 30, 31, 25, 0, 0, 13, 8, 11,
+	18, 16, 0, 26, 2, 20, 0, 32, 21,
 }
 
 var yyTok1 = [...]int8{
@@ -543,82 +544,104 @@ yydefault:
 			yylex.(*lexer).val = yyDollar[1].f
 		}
 	case 2:
-		yyDollar = yyS[yypt-5 : yypt+1]
+		yyDollar = yyS[yypt-6 : yypt+1]
 //line expressions.y:46
 		{
-			yylex.(*lexer).Assignment = Assignment{yyDollar[2].name, &expression{yyDollar[4].f}}
+			yylex.(*lexer).Assignment = Assignment{
+				Variable:  yyDollar[2].name,
+				Variables: append([]string{yyDollar[2].name}, yyDollar[3].ss...),
+				ValueFn:   &expression{evaluator: yyDollar[5].f},
+			}
 		}
 	case 3:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:49
+//line expressions.y:53
 		{
 			yylex.(*lexer).Cycle = yyDollar[2].cycle
 		}
 	case 4:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:50
+//line expressions.y:54
 		{
 			yylex.(*lexer).Loop = yyDollar[2].loop
 		}
 	case 5:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:51
+//line expressions.y:55
 		{
-			yylex.(*lexer).When = When{yyDollar[2].exprs}
+			yylex.(*lexer).When = When{Exprs: yyDollar[2].exprs}
 		}
 	case 6:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line expressions.y:56
+		{
+			yylex.(*lexer).When = When{Exprs: yyDollar[3].exprs, Contains: true}
+		}
+	case 7:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line expressions.y:54
+//line expressions.y:59
 		{
 			yyVAL.cycle = yyDollar[2].cyclefn(yyDollar[1].s)
 		}
-	case 7:
+	case 8:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:57
+//line expressions.y:62
 		{
 			h, t := yyDollar[2].s, yyDollar[3].ss
 			yyVAL.cyclefn = func(g string) Cycle { return Cycle{g, append([]string{h}, t...)} }
 		}
-	case 8:
+	case 9:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line expressions.y:61
+//line expressions.y:66
 		{
 			vals := yyDollar[1].ss
 			yyVAL.cyclefn = func(h string) Cycle { return Cycle{Values: append([]string{h}, vals...)} }
 		}
-	case 9:
+	case 10:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line expressions.y:68
+//line expressions.y:73
 		{
 			yyVAL.ss = []string{}
 		}
-	case 10:
+	case 11:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:69
+//line expressions.y:74
 		{
 			yyVAL.ss = append([]string{yyDollar[2].s}, yyDollar[3].ss...)
 		}
-	case 11:
+	case 12:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line expressions.y:78
+		{
+			yyVAL.ss = []string{}
+		}
+	case 13:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line expressions.y:79
+		{
+			yyVAL.ss = append([]string{yyDollar[2].name}, yyDollar[3].ss...)
+		}
+	case 14:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line expressions.y:72
+//line expressions.y:82
 		{
-			yyVAL.exprs = append([]Expression{&expression{yyDollar[1].f}}, yyDollar[2].exprs...)
+			yyVAL.exprs = append([]Expression{&expression{evaluator: yyDollar[1].f}}, yyDollar[2].exprs...)
 		}
-	case 12:
+	case 15:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line expressions.y:74
+//line expressions.y:84
 		{
 			yyVAL.exprs = []Expression{}
 		}
-	case 13:
+	case 16:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:75
+//line expressions.y:85
 		{
-			yyVAL.exprs = append([]Expression{&expression{yyDollar[2].f}}, yyDollar[3].exprs...)
+			yyVAL.exprs = append([]Expression{&expression{evaluator: yyDollar[2].f}}, yyDollar[3].exprs...)
 		}
-	case 14:
+	case 17:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line expressions.y:78
+//line expressions.y:88
 		{
 			s, ok := yyDollar[1].val.(string)
 			if !ok {
@@ -626,22 +649,22 @@ yydefault:
 			}
 			yyVAL.s = s
 		}
-	case 15:
+	case 18:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line expressions.y:86
+//line expressions.y:96
 		{
 			name, expr, mods := yyDollar[1].name, yyDollar[3].f, yyDollar[4].loopmods
-			yyVAL.loop = Loop{name, &expression{expr}, mods}
+			yyVAL.loop = Loop{name, &expression{evaluator: expr}, mods}
 		}
-	case 16:
+	case 19:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line expressions.y:92
+//line expressions.y:102
 		{
 			yyVAL.loopmods = loopModifiers{}
 		}
-	case 17:
+	case 20:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line expressions.y:93
+//line expressions.y:103
 		{
 			switch yyDollar[2].name {
 			case "reversed":
@@ -651,87 +674,100 @@ yydefault:
 			}
 			yyVAL.loopmods = yyDollar[1].loopmods
 		}
-	case 18:
+	case 21:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:102
+//line expressions.y:112
 		{
 			switch yyDollar[2].name {
 			case "cols":
-				yyDollar[1].loopmods.Cols = &expression{yyDollar[3].f}
+				yyDollar[1].loopmods.Cols = &expression{evaluator: yyDollar[3].f}
 			case "limit":
-				yyDollar[1].loopmods.Limit = &expression{yyDollar[3].f}
+				yyDollar[1].loopmods.Limit = &expression{evaluator: yyDollar[3].f}
 			case "offset":
-				yyDollar[1].loopmods.Offset = &expression{yyDollar[3].f}
+				yyDollar[1].loopmods.Offset = &expression{evaluator: yyDollar[3].f}
 			default:
 				panic(SyntaxError(fmt.Sprintf("undefined loop modifier %q", yyDollar[2].name)))
 			}
 			yyVAL.loopmods = yyDollar[1].loopmods
 		}
-	case 19:
+	case 22:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line expressions.y:118
+//line expressions.y:128
 		{
 			val := yyDollar[1].val
 			yyVAL.f = func(Context) values.Value { return values.ValueOf(val) }
 		}
-	case 20:
+	case 23:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line expressions.y:119
+//line expressions.y:129
 		{
 			name := yyDollar[1].name
-			yyVAL.f = func(ctx Context) values.Value { return values.ValueOf(ctx.Get(name)) }
+			if name != "empty" {
+				// empty is reported as a literal, not a variable
+				yylex.(*lexer).variables = append(yylex.(*lexer).variables, name)
+			}
+			yyVAL.f = func(ctx Context) values.Value {
+				value, ok := ctx.Get(name)
+				if !ok && name == "empty" {
+					// an unbound empty is the empty literal
+					value = values.Empty
+				}
+				return values.ValueOf(value)
+			}
 		}
-	case 21:
+	case 24:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line expressions.y:120
+//line expressions.y:144
 		{
 			yyVAL.f = makeObjectPropertyExpr(yyDollar[1].f, yyDollar[2].name)
 		}
-	case 22:
+	case 25:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line expressions.y:121
+//line expressions.y:145
 		{
 			yyVAL.f = makeIndexExpr(yyDollar[1].f, yyDollar[3].f)
 		}
-	case 23:
+	case 26:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line expressions.y:122
+//line expressions.y:146
 		{
 			yyVAL.f = makeRangeExpr(yyDollar[2].f, yyDollar[4].f)
 		}
-	case 24:
+	case 27:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:123
+//line expressions.y:147
 		{
 			yyVAL.f = yyDollar[2].f
 		}
-	case 26:
+	case 29:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:128
+//line expressions.y:152
 		{
+			yylex.(*lexer).filters = append(yylex.(*lexer).filters, yyDollar[3].name)
 			yyVAL.f = makeFilter(yyDollar[1].f, yyDollar[3].name, nil)
 		}
-	case 27:
+	case 30:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line expressions.y:129
+//line expressions.y:156
 		{
+			yylex.(*lexer).filters = append(yylex.(*lexer).filters, yyDollar[3].name)
 			yyVAL.f = makeFilter(yyDollar[1].f, yyDollar[3].name, yyDollar[4].filter_params)
 		}
-	case 28:
+	case 31:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line expressions.y:133
+//line expressions.y:163
 		{
 			yyVAL.filter_params = []valueFn{yyDollar[1].f}
 		}
-	case 29:
+	case 32:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:135
+//line expressions.y:165
 		{
 			yyVAL.filter_params = append(yyDollar[1].filter_params, yyDollar[3].f)
 		}
-	case 31:
+	case 34:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:139
+//line expressions.y:169
 		{
 			fa, fb := yyDollar[1].f, yyDollar[3].f
 			yyVAL.f = func(ctx Context) values.Value {
@@ -739,9 +775,9 @@ yydefault:
 				return values.ValueOf(a.Equal(b))
 			}
 		}
-	case 32:
+	case 35:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:146
+//line expressions.y:176
 		{
 			fa, fb := yyDollar[1].f, yyDollar[3].f
 			yyVAL.f = func(ctx Context) values.Value {
@@ -749,9 +785,9 @@ yydefault:
 				return values.ValueOf(!a.Equal(b))
 			}
 		}
-	case 33:
+	case 36:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:153
+//line expressions.y:183
 		{
 			fa, fb := yyDollar[1].f, yyDollar[3].f
 			yyVAL.f = func(ctx Context) values.Value {
@@ -759,9 +795,9 @@ yydefault:
 				return values.ValueOf(b.Less(a))
 			}
 		}
-	case 34:
+	case 37:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:160
+//line expressions.y:190
 		{
 			fa, fb := yyDollar[1].f, yyDollar[3].f
 			yyVAL.f = func(ctx Context) values.Value {
@@ -769,44 +805,44 @@ yydefault:
 				return values.ValueOf(a.Less(b))
 			}
 		}
-	case 35:
+	case 38:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:167
+//line expressions.y:197
 		{
 			fa, fb := yyDollar[1].f, yyDollar[3].f
 			yyVAL.f = func(ctx Context) values.Value {
 				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(b.Less(a) || a.Equal(b))
+				return values.ValueOf(values.LessOrEqual(b.Interface(), a.Interface()))
 			}
 		}
-	case 36:
+	case 39:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:174
+//line expressions.y:204
 		{
 			fa, fb := yyDollar[1].f, yyDollar[3].f
 			yyVAL.f = func(ctx Context) values.Value {
 				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(a.Less(b) || a.Equal(b))
+				return values.ValueOf(values.LessOrEqual(a.Interface(), b.Interface()))
 			}
 		}
-	case 37:
+	case 40:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:181
+//line expressions.y:211
 		{
 			yyVAL.f = makeContainsExpr(yyDollar[1].f, yyDollar[3].f)
 		}
-	case 39:
+	case 42:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:186
+//line expressions.y:216
 		{
 			fa, fb := yyDollar[1].f, yyDollar[3].f
 			yyVAL.f = func(ctx Context) values.Value {
 				return values.ValueOf(fa(ctx).Test() && fb(ctx).Test())
 			}
 		}
-	case 40:
+	case 43:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:192
+//line expressions.y:222
 		{
 			fa, fb := yyDollar[1].f, yyDollar[3].f
 			yyVAL.f = func(ctx Context) values.Value {
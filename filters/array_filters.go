@@ -0,0 +1,329 @@
+package filters
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/osteele/liquid/expressions"
+	"github.com/osteele/liquid/values"
+)
+
+// whereFilter selects the items whose property equals value. The property can
+// be a dotted path such as "author.name"; an item that is missing an
+// intermediate property doesn't match. Without a value, it selects the items
+// whose property is truthy.
+func whereFilter(array []interface{}, property string, value func(interface{}) interface{}) []interface{} {
+	return filterWhere(array, property, value, true)
+}
+
+// rejectFilter implements Jekyll's reject filter, the inverse of where. It
+// selects the items whose property doesn't equal value, or, without a value,
+// whose property is falsy. Items that are missing the property are selected.
+func rejectFilter(array []interface{}, property string, value func(interface{}) interface{}) []interface{} {
+	return filterWhere(array, property, value, false)
+}
+
+// filterWhere selects the items that match property and value, as described
+// for whereFilter, if keep is true; otherwise, those that don't.
+func filterWhere(array []interface{}, property string, value func(interface{}) interface{}, keep bool) []interface{} {
+	want := value(whereNoValue{})
+	_, truthy := want.(whereNoValue)
+	path := propertyPathValues(property)
+	result := []interface{}{}
+	for _, item := range array {
+		v, ok := propertyPath(item, path)
+		match := ok && (truthy && values.ValueOf(v).Test() || !truthy && values.Equal(v, want))
+		if match == keep {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// whereNoValue is the default value argument to whereFilter.
+type whereNoValue struct{}
+
+// mapFilter returns the value of a property of each item.
+func mapFilter(array []interface{}, key string) []interface{} {
+	if len(array) == 0 {
+		return nil
+	}
+	keyValue := values.ValueOf(key)
+	result := make([]interface{}, len(array))
+	for i, item := range array {
+		result[i] = values.ValueOf(item).PropertyValue(keyValue).Interface()
+	}
+	return result
+}
+
+// propertyPathValues splits a dotted property path such as "author.name", for
+// use with propertyPath.
+func propertyPathValues(property string) []values.Value {
+	names := strings.Split(property, ".")
+	path := make([]values.Value, len(names))
+	for i, name := range names {
+		path[i] = values.ValueOf(name)
+	}
+	return path
+}
+
+// propertyPath looks up a sequence of properties. It returns false if an
+// intermediate value is nil.
+func propertyPath(item interface{}, path []values.Value) (interface{}, bool) {
+	v := values.ValueOf(item)
+	for i, name := range path {
+		if i > 0 && v.Interface() == nil {
+			return nil, false
+		}
+		v = v.PropertyValue(name)
+	}
+	return v.Interface(), true
+}
+
+// whereExpFilter implements Jekyll's where_exp filter. It selects the items for
+// which expr is truthy, when name is bound to the item.
+func whereExpFilter(array []interface{}, name string, expr expressions.Closure) ([]interface{}, error) {
+	result := []interface{}{}
+	for _, item := range array {
+		match, err := testClosure(expr, name, item)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// mapExpFilter evaluates expr for each item, with name bound to the item, and
+// returns the results.
+func mapExpFilter(array []interface{}, name string, expr expressions.Closure) ([]interface{}, error) {
+	result := make([]interface{}, 0, len(array))
+	for _, item := range array {
+		value, err := expr.Bind(name, item).Evaluate()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// sortByFilter sorts the array on the value of expr, with name bound to each
+// item. Items whose key is nil sort first. The sort is stable.
+func sortByFilter(array []interface{}, name string, expr expressions.Closure) ([]interface{}, error) {
+	keys, err := mapExpFilter(array, name, expr)
+	if err != nil {
+		return nil, err
+	}
+	indices := make([]int, len(array))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		a, b := keys[indices[i]], keys[indices[j]]
+		switch {
+		case a == nil:
+			return b != nil
+		case b == nil:
+			return false
+		}
+		return values.Less(a, b)
+	})
+	result := make([]interface{}, len(array))
+	for i, k := range indices {
+		result[i] = array[k]
+	}
+	return result, nil
+}
+
+// groupByExpFilter implements Jekyll's group_by_exp filter. It returns a
+// group {"name", "items", "size"} for each distinct value of expr, in the
+// order that the values are first encountered. Items whose key is nil are
+// grouped under the empty string.
+func groupByExpFilter(array []interface{}, name string, expr expressions.Closure) ([]interface{}, error) {
+	keys, err := mapExpFilter(array, name, expr)
+	if err != nil {
+		return nil, err
+	}
+	result := []interface{}{}
+	for i, item := range array {
+		key := keys[i]
+		if key == nil {
+			key = ""
+		}
+		var group map[string]interface{}
+		for _, g := range result {
+			if values.Equal(g.(map[string]interface{})["name"], key) {
+				group = g.(map[string]interface{})
+				break
+			}
+		}
+		if group == nil {
+			group = map[string]interface{}{"name": key, "items": []interface{}{}}
+			result = append(result, group)
+		}
+		group["items"] = append(group["items"].([]interface{}), item)
+		group["size"] = len(group["items"].([]interface{}))
+	}
+	return result, nil
+}
+
+// findExpFilter implements Jekyll's find_exp filter. It returns the first item
+// for which expr is truthy, or nil.
+func findExpFilter(array []interface{}, name string, expr expressions.Closure) (interface{}, error) {
+	for _, item := range array {
+		match, err := testClosure(expr, name, item)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+// digFilter looks up a sequence of map keys and array indices. It returns nil
+// if any step is missing or out of range.
+func digFilter(value interface{}, path ...interface{}) interface{} {
+	v := values.ValueOf(value)
+	for _, step := range path {
+		v = v.IndexValue(values.ValueOf(step))
+	}
+	return v.Interface()
+}
+
+// findFilter implements Jekyll's find filter. It returns the first item whose
+// property named key equals value, or nil.
+func findFilter(array []interface{}, key string, value interface{}) interface{} {
+	keyValue := values.ValueOf(key)
+	for _, item := range array {
+		if values.Equal(values.ValueOf(item).PropertyValue(keyValue).Interface(), value) {
+			return item
+		}
+	}
+	return nil
+}
+
+// findIndexFilter returns the index of the first item whose property equals
+// value, or nil. As for where, the property can be a dotted path.
+func findIndexFilter(array []interface{}, property string, value interface{}) interface{} {
+	path := propertyPathValues(property)
+	for i, item := range array {
+		if v, ok := propertyPath(item, path); ok && values.Equal(v, value) {
+			return i
+		}
+	}
+	return nil
+}
+
+// arrayToSentenceStringFilter implements Jekyll's array_to_sentence_string
+// filter, which joins the items as an English list: "a, b, and c".
+func arrayToSentenceStringFilter(array []interface{}, connector func(string) string) string {
+	conn := connector("and")
+	ss := make([]string, len(array))
+	for i, item := range array {
+		ss[i] = fmt.Sprint(item)
+	}
+	switch len(ss) {
+	case 0:
+		return ""
+	case 1:
+		return ss[0]
+	case 2:
+		return ss[0] + " " + conn + " " + ss[1]
+	}
+	return strings.Join(ss[:len(ss)-1], ", ") + ", " + conn + " " + ss[len(ss)-1]
+}
+
+// shuffleFilter returns a copy of the array, in random order.
+func shuffleFilter(array []interface{}, r *rand.Rand) []interface{} {
+	result := append([]interface{}{}, array...)
+	r.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+	return result
+}
+
+// sampleFilter returns n random items from the array, or all of them in
+// random order if n is at least its length. If n is negative, it returns a
+// single random item instead of an array, or nil if the array is empty.
+func sampleFilter(array []interface{}, n int, r *rand.Rand) interface{} {
+	if n < 0 {
+		if len(array) == 0 {
+			return nil
+		}
+		return array[r.Intn(len(array))]
+	}
+	result := shuffleFilter(array, r)
+	if n < len(result) {
+		result = result[:n]
+	}
+	return result
+}
+
+func testClosure(expr expressions.Closure, name string, item interface{}) (bool, error) {
+	value, err := expr.Bind(name, item).Evaluate()
+	if err != nil {
+		return false, err
+	}
+	return values.ValueOf(value).Test(), nil
+}
+
+// mapEntries returns the keys and values of a map, ordered by key, or of a
+// MapSlice, in its own order. It returns nil slices if value is not a map.
+func mapEntries(value interface{}) (keys, elems []interface{}) {
+	if ms, ok := value.(yaml.MapSlice); ok {
+		for _, item := range ms {
+			keys = append(keys, item.Key)
+			elems = append(elems, item.Value)
+		}
+		return
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map {
+		return nil, nil
+	}
+	mks := rv.MapKeys()
+	keys = make([]interface{}, len(mks))
+	for i, k := range mks {
+		keys[i] = k.Interface()
+	}
+	values.Sort(keys)
+	elems = make([]interface{}, len(keys))
+	for i, k := range keys {
+		elems[i] = rv.MapIndex(reflect.ValueOf(k)).Interface()
+	}
+	return keys, elems
+}
+
+func keysFilter(value interface{}) []interface{} {
+	keys, _ := mapEntries(value)
+	return keys
+}
+
+func valuesFilter(value interface{}) []interface{} {
+	_, elems := mapEntries(value)
+	return elems
+}
+
+// entriesFilter returns a {"key", "value"} map for each map entry, in the same
+// order as keys.
+func entriesFilter(value interface{}) []interface{} {
+	keys, elems := mapEntries(value)
+	if keys == nil {
+		return nil
+	}
+	result := make([]interface{}, len(keys))
+	for i, k := range keys {
+		result[i] = map[string]interface{}{"key": k, "value": elems[i]}
+	}
+	return result
+}
@@ -0,0 +1,107 @@
+package filters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/osteele/liquid/values"
+	"github.com/stretchr/testify/require"
+)
+
+// largeCollection returns n products, for the where | sort | map benchmarks.
+func largeCollection(n int) []interface{} {
+	products := make([]interface{}, n)
+	for i := range products {
+		product := map[string]interface{}{
+			"name":     fmt.Sprintf("product-%d", i),
+			"category": fmt.Sprintf("c%d", i%7),
+			"vendor":   map[string]interface{}{"name": fmt.Sprintf("v%d", i%3)},
+		}
+		// some products are missing the sort key
+		if i%11 != 0 {
+			product["price"] = (i * 7919) % 1000
+		}
+		products[i] = product
+	}
+	return products
+}
+
+// naiveWhereSortMap is the straightforward implementation of
+// where: property, value | sort: key | map: name, against which the filters
+// are checked. It looks up each property by name, on each use.
+func naiveWhereSortMap(array []interface{}, property string, value interface{}, key, name string) []interface{} {
+	var selected []interface{}
+	for _, item := range array {
+		v := values.ValueOf(item)
+		for _, p := range strings.Split(property, ".") {
+			v = v.PropertyValue(values.ValueOf(p))
+		}
+		if values.Equal(v.Interface(), value) {
+			selected = append(selected, item)
+		}
+	}
+	sort.Sort(naiveSortable{selected, key})
+	var result []interface{}
+	for _, item := range selected {
+		result = append(result, values.ValueOf(item).PropertyValue(values.ValueOf(name)).Interface())
+	}
+	return result
+}
+
+// naiveSortable looks up the sort key on each comparison, with nil first.
+type naiveSortable struct {
+	data []interface{}
+	key  string
+}
+
+func (s naiveSortable) Len() int      { return len(s.data) }
+func (s naiveSortable) Swap(i, j int) { s.data[i], s.data[j] = s.data[j], s.data[i] }
+func (s naiveSortable) Less(i, j int) bool {
+	a := s.data[i].(map[string]interface{})[s.key]
+	b := s.data[j].(map[string]interface{})[s.key]
+	switch {
+	case a == nil:
+		return b != nil
+	case b == nil:
+		return false
+	default:
+		return values.Less(a, b)
+	}
+}
+
+func whereSortMap(array []interface{}, property string, value interface{}, key, name string) []interface{} {
+	selected := whereFilter(array, property, func(interface{}) interface{} { return value })
+	return mapFilter(sortFilter(selected, key), name)
+}
+
+func TestWhereSortMap_large(t *testing.T) {
+	products := largeCollection(10000)
+	for _, property := range []string{"category", "vendor.name"} {
+		value := "c3"
+		if property == "vendor.name" {
+			value = "v1"
+		}
+		expected := naiveWhereSortMap(products, property, value, "price", "name")
+		require.NotEmpty(t, expected)
+		require.Equal(t, expected, whereSortMap(products, property, value, "price", "name"), property)
+	}
+	require.Nil(t, mapFilter(nil, "name"))
+}
+
+func BenchmarkWhereSortMap(b *testing.B) {
+	products := largeCollection(10000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		whereSortMap(products, "category", "c3", "price", "name")
+	}
+}
+
+func BenchmarkWhereSortMap_naive(b *testing.B) {
+	products := largeCollection(10000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		naiveWhereSortMap(products, "category", "c3", "price", "name")
+	}
+}
@@ -9,13 +9,20 @@ import (
 	"github.com/osteele/liquid/values"
 )
 
-func sortFilter(array []interface{}, key interface{}) []interface{} {
+func sortFilter(array []interface{}, keys ...interface{}) []interface{} {
 	result := make([]interface{}, len(array))
 	copy(result, array)
-	if key == nil {
+	switch {
+	case len(keys) == 0 || (len(keys) == 1 && keys[0] == nil):
 		values.Sort(result)
-	} else {
-		values.SortByProperty(result, fmt.Sprint(key), true)
+	case len(keys) == 1:
+		values.SortByProperty(result, fmt.Sprint(keys[0]), true)
+	default:
+		props := make([]string, len(keys))
+		for i, k := range keys {
+			props[i] = fmt.Sprint(k)
+		}
+		values.SortByProperties(result, props, true)
 	}
 	return result
 }
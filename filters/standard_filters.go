@@ -2,17 +2,28 @@
 package filters
 
 import (
+	"crypto/hmac"
+	"crypto/md5"  // nolint: gosec
+	"crypto/sha1" // nolint: gosec
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
 	"html"
 	"math"
+	"math/rand"
 	"net/url"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
+	"github.com/osteele/liquid/expressions"
 	"github.com/osteele/liquid/values"
 	"github.com/osteele/tuesday"
 )
@@ -22,17 +33,59 @@ type FilterDictionary interface {
 	AddFilter(string, interface{})
 }
 
+// A markdownConverter is a FilterContext that can also convert Markdown to
+// HTML, using the configuration of the current render.
+type markdownConverter interface {
+	ConvertMarkdown(string) string
+}
+
+// An autoescaper is a FilterContext that escapes HTML if autoescaping is
+// enabled in the configuration of the current render.
+type autoescaper interface {
+	AutoescapeHTML(string) string
+}
+
+// An outputLimiter is a FilterContext that supplies the output limit of the
+// current render, or zero if there isn't one.
+type outputLimiter interface {
+	OutputLimit() int
+}
+
+// A randomSource is a FilterContext that supplies the source of randomness
+// for the sample and shuffle filters, from the configuration of the current
+// render.
+type randomSource interface {
+	WithRandomSource(func(*rand.Rand))
+}
+
+// withRandomSource calls fn with the context's source of randomness, or with
+// a time-seeded source if it doesn't have one.
+func withRandomSource(ctx expressions.FilterContext, fn func(*rand.Rand)) {
+	if rs, ok := ctx.(randomSource); ok {
+		rs.WithRandomSource(fn)
+		return
+	}
+	fn(rand.New(rand.NewSource(time.Now().UnixNano()))) // nolint: gosec
+}
+
 // AddStandardFilters defines the standard Liquid filters.
 func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	// value filters
+
+	// default replaces nil, false, and empty strings, arrays, and maps. Other
+	// values, including 0, are returned unchanged.
 	fd.AddFilter("default", func(value, defaultValue interface{}) interface{} {
 		if value == nil || value == false || values.IsEmpty(value) {
 			value = defaultValue
 		}
 		return value
 	})
+	fd.AddFilter("dig", digFilter)
+	fd.AddFilter("entries", entriesFilter)
+	fd.AddFilter("keys", keysFilter)
+	fd.AddFilter("values", valuesFilter)
 	fd.AddFilter("json", func(a interface{}) interface{} {
-		result, _ := json.Marshal(a)
+		result, _ := marshalJSON(a)
 		return result
 	})
 
@@ -50,14 +103,14 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 		return append(append(result, a...), b...)
 	})
 	fd.AddFilter("join", joinFilter)
-	fd.AddFilter("map", func(a []interface{}, key string) (result []interface{}) {
-		keyValue := values.ValueOf(key)
-		for _, obj := range a {
-			value := values.ValueOf(obj)
-			result = append(result, value.PropertyValue(keyValue).Interface())
-		}
-		return result
-	})
+	// push is from Jekyll. Like concat, it returns a new array, so that pushing
+	// to the same array more than once doesn't alias the results.
+	fd.AddFilter("push", func(a []interface{}, item interface{}) []interface{} {
+		result := make([]interface{}, 0, len(a)+1)
+		return append(append(result, a...), item)
+	})
+	fd.AddFilter("map", mapFilter)
+	fd.AddFilter("map_exp", mapExpFilter)
 	fd.AddFilter("reverse", reverseFilter)
 	fd.AddFilter("sort", sortFilter)
 	// https://shopify.github.io/liquid/ does not demonstrate first and last as filters,
@@ -75,20 +128,60 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 		return a[len(a)-1]
 	})
 	fd.AddFilter("uniq", uniqFilter)
+	// sample and shuffle are from Jekyll and Shopify, respectively
+	fd.AddFilter("sample", func(ctx expressions.FilterContext, a []interface{}, n func(int) int) (result interface{}) {
+		count := n(-1)
+		withRandomSource(ctx, func(r *rand.Rand) { result = sampleFilter(a, count, r) })
+		return result
+	})
+	fd.AddFilter("shuffle", func(ctx expressions.FilterContext, a []interface{}) (result []interface{}) {
+		withRandomSource(ctx, func(r *rand.Rand) { result = shuffleFilter(a, r) })
+		return result
+	})
+	fd.AddFilter("where", whereFilter)
+	fd.AddFilter("find_index", findIndexFilter)
+	// where_exp, find, find_exp, reject, sort_by, group_by_exp, and
+	// array_to_sentence_string are from Jekyll
+	fd.AddFilter("array_to_sentence_string", arrayToSentenceStringFilter)
+	fd.AddFilter("sort_by", sortByFilter)
+	fd.AddFilter("group_by_exp", groupByExpFilter)
+	fd.AddFilter("where_exp", whereExpFilter)
+	fd.AddFilter("find", findFilter)
+	fd.AddFilter("find_exp", findExpFilter)
+	fd.AddFilter("reject", rejectFilter)
 
 	// date filters
+	// date writes nothing for nil and the zero time, instead of a formatted
+	// zero date, as in Shopify. A string that isn't a date is an error.
 	fd.AddFilter("date", func(t time.Time, format func(string) string) (string, error) {
+		if t.IsZero() {
+			return "", nil
+		}
 		f := format("%a, %b %d, %y")
 		return tuesday.Strftime(f, t)
 	})
+	// date_to_xmlschema, date_to_rfc822, date_to_string, and date_to_long_string
+	// are from Jekyll
+	fd.AddFilter("date_to_xmlschema", func(t time.Time) string {
+		return t.Format(time.RFC3339)
+	})
+	fd.AddFilter("date_to_rfc822", func(t time.Time) string {
+		return t.Format(time.RFC1123Z)
+	})
+	fd.AddFilter("date_to_string", func(t time.Time, typ, style func(string) string) string {
+		return stringifyDate(t, "Jan", typ(""), style(""))
+	})
+	fd.AddFilter("date_to_long_string", func(t time.Time, typ, style func(string) string) string {
+		return stringifyDate(t, "January", typ(""), style(""))
+	})
 
 	// number filters
-	fd.AddFilter("abs", math.Abs)
-	fd.AddFilter("ceil", func(a float64) int {
-		return int(math.Ceil(a))
+	fd.AddFilter("abs", absFilter)
+	fd.AddFilter("ceil", func(a float64, places func(int) int) interface{} {
+		return roundWith(math.Ceil, a, places(0))
 	})
-	fd.AddFilter("floor", func(a float64) int {
-		return int(math.Floor(a))
+	fd.AddFilter("floor", func(a float64, places func(int) int) interface{} {
+		return roundWith(math.Floor, a, places(0))
 	})
 	fd.AddFilter("modulo", math.Mod)
 	fd.AddFilter("minus", func(a, b float64) float64 {
@@ -97,19 +190,8 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	fd.AddFilter("plus", func(a, b float64) float64 {
 		return a + b
 	})
-	fd.AddFilter("times", func(a, b float64) float64 {
-		return a * b
-	})
-	fd.AddFilter("divided_by", func(a float64, b interface{}) interface{} {
-		switch q := b.(type) {
-		case int, int16, int32, int64:
-			return int(a) / q.(int)
-		case float32, float64:
-			return a / b.(float64)
-		default:
-			return nil
-		}
-	})
+	fd.AddFilter("times", timesFilter)
+	fd.AddFilter("divided_by", dividedByFilter)
 	fd.AddFilter("round", func(n float64, places func(int) int) float64 {
 		pl := places(0)
 		exp := math.Pow10(pl)
@@ -129,6 +211,7 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 		}
 		return strings.ToUpper(s[:1]) + s[1:]
 	})
+	fd.AddFilter("titleize", titleizeFilter)
 	fd.AddFilter("downcase", func(s, suffix string) string {
 		return strings.ToLower(s)
 	})
@@ -136,6 +219,16 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	fd.AddFilter("escape_once", func(s, suffix string) string {
 		return html.EscapeString(html.UnescapeString(s))
 	})
+	// camelize, underscore and dasherize are from Rails
+	fd.AddFilter("camelize", camelizeFilter)
+	fd.AddFilter("dasherize", func(s string) string {
+		return strings.Replace(s, "_", "-", -1)
+	})
+	fd.AddFilter("underscore", underscoreFilter)
+	fd.AddFilter("handle", handleizeFilter)
+	fd.AddFilter("handleize", handleizeFilter)
+	// slugify is from Jekyll
+	fd.AddFilter("slugify", slugifyFilter)
 	fd.AddFilter("newline_to_br", func(s string) string {
 		return strings.Replace(s, "\n", "<br />", -1)
 	})
@@ -154,41 +247,53 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	fd.AddFilter("replace_first", func(s, old, new string) string {
 		return strings.Replace(s, old, new, 1)
 	})
+	// regex_replace is from the jekyll-regex-replace plugin
+	fd.AddFilter("regex_replace", regexReplaceFilter)
+	fd.AddFilter("match", matchFilter)
+	fd.AddFilter("scan", scanFilter)
 	fd.AddFilter("sort_natural", sortNaturalFilter)
-	fd.AddFilter("slice", func(s string, start int, length func(int) int) string {
-		ss := []rune(s)
-		n := length(1)
-		if start < 0 {
-			start = len(ss) + start
-		}
-		end := start + n
-		if end > len(ss) {
-			end = len(ss)
-		}
-		return string(ss[start:end])
-	})
+	fd.AddFilter("slice", sliceFilter)
 	fd.AddFilter("split", splitFilter)
+	fd.AddFilter("substring", sliceFilter)
 	fd.AddFilter("strip_html", func(s string) string {
 		// TODO this probably isn't sufficient
 		return regexp.MustCompile(`<.*?>`).ReplaceAllString(s, "")
 	})
+	// smartify is from Jekyll
+	fd.AddFilter("smartify", smartifyFilter)
+	// normalize_whitespace is from Jekyll
+	fd.AddFilter("normalize_whitespace", normalizeWhitespaceFilter)
+	fd.AddFilter("strip_whitespace", normalizeWhitespaceFilter)
 	fd.AddFilter("strip_newlines", func(s string) string {
 		return strings.Replace(s, "\n", "", -1)
 	})
-	fd.AddFilter("strip", strings.TrimSpace)
-	fd.AddFilter("lstrip", func(s string) string {
+	// strip, lstrip and rstrip remove whitespace, or the characters in their
+	// optional argument.
+	fd.AddFilter("strip", func(s string, chars func(string) string) string {
+		if cs := chars(""); cs != "" {
+			return strings.Trim(s, cs)
+		}
+		return strings.TrimSpace(s)
+	})
+	fd.AddFilter("lstrip", func(s string, chars func(string) string) string {
+		if cs := chars(""); cs != "" {
+			return strings.TrimLeft(s, cs)
+		}
 		return strings.TrimLeftFunc(s, unicode.IsSpace)
 	})
-	fd.AddFilter("rstrip", func(s string) string {
+	fd.AddFilter("rstrip", func(s string, chars func(string) string) string {
+		if cs := chars(""); cs != "" {
+			return strings.TrimRight(s, cs)
+		}
 		return strings.TrimRightFunc(s, unicode.IsSpace)
 	})
-	fd.AddFilter("truncate", func(s string, length func(int) int, ellipsis func(string) string) string {
-		n := length(50)
-		el := ellipsis("...")
-		// runes aren't bytes; don't use slice
-		re := regexp.MustCompile(fmt.Sprintf(`^(.{%d})..{%d,}`, n-len(el), len(el)))
-		return re.ReplaceAllString(s, `$1`+el)
+	fd.AddFilter("pad_left", func(s string, width int, pad func(string) string) string {
+		return padding(s, width, pad(" ")) + s
+	})
+	fd.AddFilter("pad_right", func(s string, width int, pad func(string) string) string {
+		return s + padding(s, width, pad(" "))
 	})
+	fd.AddFilter("truncate", truncateFilter)
 	fd.AddFilter("truncatewords", func(s string, length func(int) int, ellipsis func(string) string) string {
 		el := ellipsis("...")
 		n := length(15)
@@ -202,15 +307,69 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	fd.AddFilter("upcase", func(s, suffix string) string {
 		return strings.ToUpper(s)
 	})
+	fd.AddFilter("word_wrap", wordWrapFilter)
 	fd.AddFilter("url_encode", url.QueryEscape)
 	fd.AddFilter("url_decode", url.QueryUnescape)
+	// url_escape and url_param_escape are from Shopify. Unlike url_encode, they
+	// encode a space as %20. url_escape leaves the characters that structure a
+	// URL alone; url_param_escape also encodes those that structure a query
+	// string, so that the result can be used as a parameter value.
+	fd.AddFilter("url_escape", func(s string) string {
+		return percentEncode(s, "-_.~!*'();/?:@&=+$,#")
+	})
+	fd.AddFilter("url_param_escape", func(s string) string {
+		return percentEncode(s, "-_.~!*'();/?:@$,")
+	})
+	// xml_escape and cgi_escape are from Jekyll. xml_escape uses the XML
+	// entity for a double quote, rather than a numeric reference. cgi_escape
+	// is Ruby's CGI.escape, which encodes a space as +.
+	fd.AddFilter("xml_escape", xmlEscapeReplacer.Replace)
+	fd.AddFilter("cgi_escape", url.QueryEscape)
+
+	// markdownify is from Jekyll. It uses the Markdown converter of the render's
+	// configuration, and is the identity function if there isn't one.
+	fd.AddFilter("markdownify", func(ctx expressions.FilterContext, s string) string {
+		if mc, ok := ctx.(markdownConverter); ok {
+			return mc.ConvertMarkdown(s)
+		}
+		return s
+	})
+
+	// encoding filters
+	fd.AddFilter("base64_encode", func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	})
+	fd.AddFilter("base64_decode", func(s string) (string, error) {
+		return base64Decode(base64.StdEncoding, s)
+	})
+	fd.AddFilter("base64_url_safe_encode", func(s string) string {
+		return base64.URLEncoding.EncodeToString([]byte(s))
+	})
+	fd.AddFilter("base64_url_safe_decode", func(s string) (string, error) {
+		return base64Decode(base64.URLEncoding, s)
+	})
+
+	// digest filters
+	// These are from Shopify. The output is lowercase hex.
+	fd.AddFilter("md5", func(s string) string {
+		return hexDigest(md5.New(), s)
+	})
+	fd.AddFilter("sha1", func(s string) string {
+		return hexDigest(sha1.New(), s)
+	})
+	fd.AddFilter("sha256", func(s string) string {
+		return hexDigest(sha256.New(), s)
+	})
+	fd.AddFilter("hmac_sha256", func(s, key string) string {
+		return hexDigest(hmac.New(sha256.New, []byte(key)), s)
+	})
 
 	// debugging filters
 	// inspect is from Jekyll
 	fd.AddFilter("inspect", func(value interface{}) string {
-		s, err := json.Marshal(value)
+		s, err := marshalJSON(value)
 		if err != nil {
-			return fmt.Sprintf("%#v", value)
+			return fmt.Sprintf("%#v", breakCycles(reflect.ValueOf(value), map[visit]bool{}))
 		}
 		return string(s)
 	})
@@ -219,17 +378,419 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	})
 }
 
-func joinFilter(a []interface{}, sep func(string) string) interface{} {
+func joinFilter(ctx expressions.FilterContext, a []interface{}, sep func(string) string) interface{} {
+	escape := func(s string) string { return s }
+	if ae, ok := ctx.(autoescaper); ok {
+		escape = ae.AutoescapeHTML
+	}
 	ss := make([]string, 0, len(a))
 	s := sep(" ")
 	for _, v := range a {
 		if v != nil {
-			ss = append(ss, fmt.Sprint(v))
+			ss = append(ss, escape(fmt.Sprint(v)))
 		}
 	}
 	return strings.Join(ss, s)
 }
 
+// dividedByFilter implements divided_by. The quotient is an integer if the
+// divisor is an integer, or a string that parses as one.
+func dividedByFilter(a float64, b interface{}) (interface{}, error) {
+	if s, ok := b.(string); ok {
+		b = parseNumber(s)
+	}
+	rv := reflect.ValueOf(b)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Int() == 0 {
+			return nil, expressions.InterpreterError("divided by 0")
+		}
+		return int(a) / int(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() == 0 {
+			return nil, expressions.InterpreterError("divided by 0")
+		}
+		return int(a) / int(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return a / rv.Float(), nil
+	default:
+		return nil, nil
+	}
+}
+
+// padding returns the string that pads s to width characters, as Ruby's
+// String#rjust and String#ljust do: pad is repeated, and the last repetition
+// cut short as necessary. It is empty if s is at least width characters long.
+func padding(s string, width int, pad string) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 || pad == "" {
+		return ""
+	}
+	rs := []rune(strings.Repeat(pad, n/utf8.RuneCountInString(pad)+1))
+	return string(rs[:n])
+}
+
+// timesFilter multiplies two numbers. If a is a string that isn't a number, it
+// returns a repeated b times instead, as Ruby's String#* does. The result may
+// not be longer than the render's output limit, or 2GiB if it doesn't have one.
+func timesFilter(ctx expressions.FilterContext, a interface{}, b float64) (interface{}, error) {
+	if s, ok := a.(string); ok && parseNumber(s) == nil {
+		if b < 0 {
+			return nil, expressions.InterpreterError("negative argument to times")
+		}
+		max := math.MaxInt32
+		if ol, ok := ctx.(outputLimiter); ok && ol.OutputLimit() > 0 {
+			max = ol.OutputLimit()
+		}
+		if float64(len(s))*math.Floor(b) > float64(max) {
+			return nil, expressions.InterpreterError(fmt.Sprintf("times would repeat the string past the limit of %d bytes", max))
+		}
+		return strings.Repeat(s, int(b)), nil
+	}
+	if a == nil {
+		return 0.0, nil
+	}
+	n, err := values.Convert(a, reflect.TypeOf(b))
+	if err != nil {
+		return nil, err
+	}
+	return n.(float64) * b, nil
+}
+
+// absFilter returns the absolute value of a number, with the same type: an
+// int for an integer, and a float64 for a floating-point number. A string is
+// parsed as an int if it can be, else as a float64. Other values are zero.
+func absFilter(a interface{}) interface{} {
+	if s, ok := a.(string); ok {
+		a = parseNumber(s)
+	}
+	rv := reflect.ValueOf(a)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := int(rv.Int())
+		if n < 0 {
+			n = -n
+		}
+		return n
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return math.Abs(rv.Float())
+	default:
+		return 0
+	}
+}
+
+// parseNumber parses s as an int if it can, else as a float64. It returns nil
+// if s isn't a number.
+func parseNumber(s string) interface{} {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return nil
+}
+
+// stringifyDate formats t as Jekyll's date_to_string and date_to_long_string
+// do, with month as the Go layout for the month name. If typ is "ordinal" the
+// day is an ordinal number, and if style is also "US" the month comes first.
+func stringifyDate(t time.Time, month, typ, style string) string {
+	if typ != "ordinal" {
+		return t.Format("02 " + month + " 2006")
+	}
+	day := ordinal(t.Day())
+	if style == "US" {
+		return t.Format(month) + " " + day + ", " + t.Format("2006")
+	}
+	return day + " " + t.Format(month+" 2006")
+}
+
+// ordinal returns n with an English ordinal suffix, such as "1st" or "12th".
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+func base64Decode(enc *base64.Encoding, s string) (string, error) {
+	b, err := enc.DecodeString(s)
+	if err != nil {
+		return "", expressions.InterpreterError(fmt.Sprintf("invalid base64 input %q", s))
+	}
+	return string(b), nil
+}
+
+var handleizeRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// truncateFilter implements truncate. If htmlSafe is true, a character
+// reference such as &amp; that would be cut is removed entirely, so that the
+// result remains valid HTML.
+func truncateFilter(s string, length func(int) int, ellipsis func(string) string, htmlSafe func(bool) bool) string {
+	n := length(50)
+	el := ellipsis("...")
+	// runes aren't bytes; don't use slice
+	re := regexp.MustCompile(fmt.Sprintf(`^(.{%d})..{%d,}`, n-len(el), len(el)))
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return s
+	}
+	kept := m[1]
+	if htmlSafe(false) {
+		if i := strings.LastIndexByte(kept, '&'); i >= 0 && !strings.Contains(kept[i:], ";") &&
+			htmlCharRefRe.MatchString(s[i:]) {
+			kept = kept[:i]
+		}
+	}
+	return kept + el + s[len(m[0]):]
+}
+
+var xmlEscapeReplacer = strings.NewReplacer(
+	"&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&#39;",
+)
+
+var htmlCharRefRe = regexp.MustCompile(`^&(?:[[:alnum:]]+|#[0-9]+|#[xX][[:xdigit:]]+);`)
+
+var smartifyReplacer = strings.NewReplacer("...", "…", "---", "—", "--", "—")
+
+// smartifyFilter replaces straight quotes, dashes, and ellipses by their
+// typographic equivalents.
+func smartifyFilter(s string) string {
+	rs := []rune(smartifyReplacer.Replace(s))
+	// opens reports whether a quote at i opens a quotation.
+	opens := func(i int) bool {
+		return i == 0 || unicode.IsSpace(rs[i-1]) || strings.ContainsRune("([{<—–“‘", rs[i-1])
+	}
+	for i, r := range rs {
+		switch r {
+		case '"':
+			if opens(i) {
+				rs[i] = '“'
+			} else {
+				rs[i] = '”'
+			}
+		case '\'':
+			// An apostrophe, as in a contraction, is a closing quote.
+			if opens(i) && !(i+1 < len(rs) && unicode.IsDigit(rs[i+1])) {
+				rs[i] = '‘'
+			} else {
+				rs[i] = '’'
+			}
+		}
+	}
+	return string(rs)
+}
+
+// handleizeFilter implements Shopify's handle and handleize filters. Non-ASCII
+// characters are removed, rather than transliterated.
+func handleizeFilter(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+	return strings.Trim(handleizeRe.ReplaceAllString(s, "-"), "-")
+}
+
+var (
+	wordSeparatorRe   = regexp.MustCompile(`[-_\s]+`)
+	acronymBoundaryRe = regexp.MustCompile(`([A-Z\d]+)([A-Z][a-z])`)
+	wordBoundaryRe    = regexp.MustCompile(`([a-z\d])([A-Z])`)
+)
+
+// camelizeFilter joins the words of s, which are separated by underscores,
+// hyphens or spaces, and capitalizes the first letter of each. Other letters
+// keep their case, so that acronyms are preserved.
+func camelizeFilter(s string) string {
+	words := wordSeparatorRe.Split(s, -1)
+	for i, w := range words {
+		if r, n := utf8.DecodeRuneInString(w); n > 0 {
+			words[i] = string(unicode.ToUpper(r)) + w[n:]
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// underscoreFilter converts a camel-cased s to lowercase words separated by
+// underscores. Hyphens and spaces also separate words, and an acronym is a
+// single word: "HTMLParser" becomes "html_parser".
+func underscoreFilter(s string) string {
+	s = acronymBoundaryRe.ReplaceAllString(s, "${1}_$2")
+	s = wordBoundaryRe.ReplaceAllString(s, "${1}_$2")
+	return strings.ToLower(wordSeparatorRe.ReplaceAllString(s, "_"))
+}
+
+// wordWrapFilter breaks each line of s into lines of at most width
+// characters, at whitespace. A word that is longer than width is on a line of
+// its own. Runs of whitespace within a line are replaced by single spaces.
+func wordWrapFilter(s string, width int) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		var (
+			buf strings.Builder
+			n   int
+		)
+		for _, word := range strings.Fields(line) {
+			wn := utf8.RuneCountInString(word)
+			switch {
+			case n == 0:
+			case n+1+wn > width:
+				buf.WriteByte('\n')
+				n = 0
+			default:
+				buf.WriteByte(' ')
+				n++
+			}
+			buf.WriteString(word)
+			n += wn
+		}
+		lines[i] = buf.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// titleizeFilter capitalizes the first letter of each word in s. A word
+// begins after whitespace or a hyphen. As with capitalize, the other letters
+// are unchanged.
+func titleizeFilter(s string) string {
+	start := true
+	return strings.Map(func(r rune) rune {
+		if start {
+			r = unicode.ToUpper(r)
+		}
+		start = unicode.IsSpace(r) || r == '-'
+		return r
+	}, s)
+}
+
+// slugifyModes maps each slugify mode to the pattern of characters that it
+// replaces by hyphens. There's no transliteration, so "latin" is the same as
+// "ascii".
+var slugifyModes = map[string]*regexp.Regexp{
+	"raw":     regexp.MustCompile(`\s+`),
+	"default": regexp.MustCompile(`[^\p{M}\p{L}\p{Nd}]+`),
+	"pretty":  regexp.MustCompile(`[^\p{M}\p{L}\p{Nd}._~!$&'()+,;=@]+`),
+	"ascii":   regexp.MustCompile(`[^a-zA-Z0-9]+`),
+	"latin":   regexp.MustCompile(`[^a-zA-Z0-9]+`),
+}
+
+// slugifyFilter implements Jekyll's slugify filter. An unrecognized mode is
+// treated as "default".
+func slugifyFilter(s string, mode func(string) string) string {
+	m := mode("default")
+	if m == "none" {
+		return s
+	}
+	re, ok := slugifyModes[m]
+	if !ok {
+		re = slugifyModes["default"]
+	}
+	return strings.ToLower(strings.Trim(re.ReplaceAllString(s, "-"), "-"))
+}
+
+// marshalJSON is json.Marshal, except that a map, slice or pointer that
+// contains itself is written as "..." where it recurs, instead of failing.
+func marshalJSON(value interface{}) ([]byte, error) {
+	b, err := json.Marshal(value)
+	if _, ok := err.(*json.UnsupportedValueError); ok {
+		b, err = json.Marshal(breakCycles(reflect.ValueOf(value), map[visit]bool{}))
+	}
+	return b, err
+}
+
+// A visit is a map, slice or pointer on the path to the current value in
+// breakCycles.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// breakCycles returns a copy of v in which each map, slice or pointer that
+// recurs inside itself is replaced by "...". Maps and slices are copied as
+// map[K]interface{} and []interface{}; other values are returned as is.
+func breakCycles(v reflect.Value, path map[visit]bool) interface{} {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Interface:
+		return breakCycles(v.Elem(), path)
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Slice && (v.Len() == 0 || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface()
+		}
+		key := visit{v.Pointer(), v.Type()}
+		if path[key] {
+			return "..."
+		}
+		path[key] = true
+		defer delete(path, key)
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		return breakCycles(v.Elem(), path)
+	case reflect.Map:
+		elemType := reflect.TypeOf([]interface{}{}).Elem()
+		result := reflect.MakeMapWithSize(reflect.MapOf(v.Type().Key(), elemType), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.Zero(elemType)
+			if x := breakCycles(iter.Value(), path); x != nil {
+				elem = reflect.ValueOf(x)
+			}
+			result.SetMapIndex(iter.Key(), elem)
+		}
+		return result.Interface()
+	case reflect.Slice, reflect.Array:
+		result := make([]interface{}, v.Len())
+		for i := range result {
+			result[i] = breakCycles(v.Index(i), path)
+		}
+		return result
+	default:
+		return v.Interface()
+	}
+}
+
+func hexDigest(h hash.Hash, s string) string {
+	h.Write([]byte(s)) // nolint: errcheck
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// percentEncode percent-encodes each byte of s that is not an ASCII letter or
+// digit, and not in safe.
+func percentEncode(s, safe string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			b.WriteByte(c)
+		case strings.IndexByte(safe, c) >= 0:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+	return b.String()
+}
+
 func reverseFilter(a []interface{}) interface{} {
 	result := make([]interface{}, len(a))
 	for i, x := range a {
@@ -238,13 +799,125 @@ func reverseFilter(a []interface{}) interface{} {
 	return result
 }
 
+// sliceFilter operates on runes, not bytes, so that a multibyte character is
+// never split. It is also registered as "substring".
+//
+// The length defaults to 1. A negative start counts from the end of s. As in
+// Shopify, the result is empty if start is before the beginning or after the
+// end of s, or if the length is negative.
+func sliceFilter(s string, start int, length func(int) int) string {
+	ss := []rune(s)
+	n := length(1)
+	if start < 0 {
+		start = len(ss) + start
+	}
+	if start < 0 || start > len(ss) || n < 0 {
+		return ""
+	}
+	end := start + n
+	if end > len(ss) {
+		end = len(ss)
+	}
+	return string(ss[start:end])
+}
+
 var wsre = regexp.MustCompile(`[[:space:]]+`)
 
+// normalizeWhitespaceFilter replaces each run of whitespace by a single space,
+// and removes whitespace from the ends.
+func normalizeWhitespaceFilter(s string) string {
+	return strings.TrimSpace(wsre.ReplaceAllString(s, " "))
+}
+
+// roundWith rounds n to places decimal places with fn, which is math.Ceil or
+// math.Floor. It returns an int if places is zero, and a float64 otherwise.
+func roundWith(fn func(float64) float64, n float64, places int) interface{} {
+	if places == 0 {
+		return int(fn(n))
+	}
+	exp := math.Pow10(places)
+	x := n * exp
+	// Don't let a representation error such as 0.29 * 100 = 28.999999999999996
+	// move the result to the next step.
+	if r := math.Round(x); math.Abs(x-r) < 1e-9 {
+		x = r
+	}
+	return fn(x) / exp
+}
+
+// regexReplaceFilter replaces each match of the regular expression pattern in
+// s. The replacement can refer to capture groups as $1 or ${name}.
+func regexReplaceFilter(s, pattern, replacement string) (string, error) {
+	re, err := compileFilterRegexp(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.ReplaceAllString(s, replacement), nil
+}
+
+// matchFilter returns the first match of the regular expression pattern in s,
+// or nil if there is none. If the pattern has a capture group, it returns the
+// text of the first group instead of the whole match.
+func matchFilter(s, pattern string) (interface{}, error) {
+	re, err := compileFilterRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+	m := re.FindStringSubmatch(s)
+	switch {
+	case m == nil:
+		return nil, nil
+	case len(m) > 1:
+		return m[1], nil
+	default:
+		return m[0], nil
+	}
+}
+
+// scanFilter returns all the matches of the regular expression pattern in s,
+// as Ruby's String#scan does. If the pattern has one capture group, the items
+// are the text of that group; if it has several, each item is an array of
+// their texts.
+func scanFilter(s, pattern string) ([]interface{}, error) {
+	re, err := compileFilterRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+	result := []interface{}{}
+	for _, m := range re.FindAllStringSubmatch(s, -1) {
+		switch len(m) {
+		case 1:
+			result = append(result, m[0])
+		case 2:
+			result = append(result, m[1])
+		default:
+			groups := make([]interface{}, len(m)-1)
+			for i, g := range m[1:] {
+				groups[i] = g
+			}
+			result = append(result, groups)
+		}
+	}
+	return result, nil
+}
+
+func compileFilterRegexp(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, expressions.InterpreterError(fmt.Sprintf("invalid regular expression %q: %s", pattern, err))
+	}
+	return re, nil
+}
+
+// splitFilter splits s around sep, as Ruby's String#split does. Empty fields
+// at the end are dropped; those at the start and in the middle are kept. A
+// separator of " " splits around runs of whitespace, and ignores whitespace
+// at the start.
 func splitFilter(s, sep string) interface{} {
 	result := strings.Split(s, sep)
 	if sep == " " {
 		// Special case for Ruby, therefore Liquid
-		result = wsre.Split(s, -1)
+		result = wsre.Split(strings.TrimLeft(s, " \t\n\v\f\r"), -1)
 	}
 	// This matches Ruby / Liquid / Jekyll's observed behavior.
 	for len(result) > 0 && result[len(result)-1] == "" {
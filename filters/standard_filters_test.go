@@ -2,7 +2,10 @@ package filters
 
 import (
 	"fmt"
+	"math/rand"
 	"os"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -25,9 +28,37 @@ var filterTests = []struct {
 	{`empty_map | default: 2.99`, 2.99},
 	{`empty_map_slice | default: 2.99`, 2.99},
 	{`true | default: 2.99`, true},
+	{`0 | default: 2.99`, 0},
+	{`0.0 | default: 2.99`, 0.0},
+	{`"0" | default: 2.99`, "0"},
+	{`" " | default: 2.99`, " "},
+	{`empty | default: 2.99`, 2.99},
+	{`empty_array | default: empty_map | default: 2.99`, 2.99},
 	{`"true" | default: 2.99`, "true"},
 	{`4.99 | default: 2.99`, 4.99},
 	{`fruits | default: 2.99 | join`, "apples oranges peaches plums"},
+	{`nested | dig: "a", "b", 0`, "x"},
+	{`nested | dig: "a", "b", 1, "c"`, "y"},
+	{`nested | dig: "a", "b", -1, "c"`, "y"},
+	{`nested | dig: "a", "b" | size`, 2},
+	{`nested | dig: "a", "missing", 0`, nil},
+	{`nested | dig: "a", "b", 5`, nil},
+	{`nested | dig: "a", "b", 0, "c"`, nil},
+	{`nested | dig`, map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{"x", map[string]interface{}{"c": "y"}}}}},
+	{`nil | dig: "a"`, nil},
+	{`map | keys`, []interface{}{"a"}},
+	{`map | values`, []interface{}{1}},
+	{`multi_key_map | keys | join`, "a b c"},
+	{`multi_key_map | values | join`, "1 2 3"},
+	{`map_slice_2 | keys | join`, "1 2"},
+	{`map_slice_2 | values | join`, "b a"},
+	{`empty_map | keys | size`, 0},
+	{`fruits | keys`, []interface{}(nil)},
+	{`map | entries | inspect`, `[{"key":"a","value":1}]`},
+	{`multi_key_map | entries | inspect`, `[{"key":"a","value":1},{"key":"b","value":2},{"key":"c","value":3}]`},
+	{`multi_key_map | entries | map: "key" | join`, "a b c"},
+	{`map_slice_2 | entries | map: "value" | join`, "b a"},
+	{`empty_map | entries | size`, 0},
 	{`"string" | json`, "\"string\""},
 	{`true | json`, "true"},
 	{`1 | json`, "1"},
@@ -37,10 +68,19 @@ var filterTests = []struct {
 	{`pages | map: 'category' | compact | join`, "business celebrities lifestyle sports technology"},
 	{`"mangos bananas persimmons" | split: " " | concat: fruits | join: ", "`, "mangos, bananas, persimmons, apples, oranges, peaches, plums"},
 	{`"John, Paul, George, Ringo" | split: ", " | join: " and "`, "John and Paul and George and Ringo"},
+	{`fruits | push: "kiwis" | join`, "apples oranges peaches plums kiwis"},
+	{`empty_array | push: 1 | push: 2 | join`, "1 2"},
 	{`",John, Paul, George, Ringo" | split: ", " | join: " and "`, ",John and Paul and George and Ringo"},
 	{`"John, Paul, George, Ringo," | split: ", " | join: " and "`, "John and Paul and George and Ringo,"},
 	{`animals | sort | join: ", "`, "Sally Snake, giraffe, octopus, zebra"},
+	// without autoescape, join doesn't escape the items (see TestFilters_autoescape)
+	{`"<a>|<b>" | split: "|" | join: ", "`, "<a>, <b>"},
+	{`"<a>|<b>" | split: "|" | join: "<br>"`, "<a><br><b>"},
+	{`"<a>|<b>" | split: "|" | join: ", " | escape`, "&lt;a&gt;, &lt;b&gt;"},
 	{`sort_prop | sort: "weight" | inspect`, `[{"weight":null},{"weight":1},{"weight":3},{"weight":5}]`},
+	{`pages | reverse | sort: "category", "name" | map: "name" | join: ", "`, "page 3, page 6, page 1, page 2, page 4, page 5, page 7"},
+	{`products | sort: "price", "title" | map: "title" | join`, "c b a"},
+	{`posts | sort: "tag", "title" | map: "title" | join`, "n b c a"},
 	{`fruits | reverse | join: ", "`, "plums, peaches, oranges, apples"},
 	{`fruits | first`, "apples"},
 	{`fruits | last`, "plums"},
@@ -53,6 +93,62 @@ var filterTests = []struct {
 	{`mixed_case_array | sort_natural | join`, "a B c"},
 	{`mixed_case_hash_values | sort_natural: 'key' | map: 'key' | join`, "a B c"},
 
+	{`pages | where: "category", "lifestyle" | map: "name" | join`, "page 4"},
+	{`pages | where: "category" | size`, 5},
+	{`pages | where: "category", "missing" | size`, 0},
+	{`books | where: "author.name", "Sam" | map: "title" | join`, "a c"},
+	{`books | where: "author.address.city", "Paris" | map: "title" | join`, "b"},
+	{`books | where: "author.name" | map: "title" | join`, "a b c"},
+	{`pages | reject: "category", "lifestyle" | map: "name" | join: ","`, "page 1,page 2,page 3,page 5,page 6,page 7"},
+	{`pages | reject: "category", "missing" | size`, 7},
+	{`pages | reject: "category" | map: "name" | join: ","`, "page 3,page 6"},
+	{`pages | reject: "category" | reject: "name" | size`, 0},
+	{`books | reject: "author.name", "Sam" | map: "title" | join`, "b d e"},
+	{`books | reject: "author.address.city", "Paris" | map: "title" | join`, "a c d e"},
+	{`empty_array | reject: "category" | size`, 0},
+
+	{`sort_prop | where_exp: "item", "item.weight > 2" | map: "weight" | join`, "5 3"},
+	{`sort_prop | where_exp: "item", "item.weight == 1" | map: "weight" | join`, "1"},
+	{`sort_prop | where_exp: "item", "item.weight" | size`, 3},
+	{`sort_prop | where_exp: "item", "item.weight > 10" | size`, 0},
+	{`fruits | where_exp: "f", "f contains 'p'" | join`, "apples peaches plums"},
+	{`sort_prop | find_exp: "item", "item.weight > 2" | inspect`, `{"weight":5}`},
+	{`sort_prop | find_exp: "item", "item.weight > 10"`, nil},
+	{`sort_prop | find: "weight", 3 | inspect`, `{"weight":3}`},
+	{`pages | find: "category", "lifestyle" | inspect`, `{"category":"lifestyle","name":"page 4"}`},
+	{`pages | find: "category", "missing"`, nil},
+	{`pages | find_index: "category", "lifestyle"`, 3},
+	{`pages | find_index: "category", "business"`, 0},
+	{`pages | find_index: "category", "missing"`, nil},
+	{`pages | find_index: "category", nil`, 2},
+	{`books | find_index: "author.name", "Sam"`, 0},
+	{`books | find_index: "author.address.city", "Paris"`, 1},
+	{`books | find_index: "author.name", "Lee"`, nil},
+	{`sort_prop | find_index: "weight", "3"`, nil},
+	{`empty_array | find_index: "category", "lifestyle"`, nil},
+	{`empty_array | array_to_sentence_string`, ""},
+	{`"a" | split: "," | array_to_sentence_string`, "a"},
+	{`"a,b" | split: "," | array_to_sentence_string`, "a and b"},
+	{`"a,b,c" | split: "," | array_to_sentence_string`, "a, b, and c"},
+	{`fruits | array_to_sentence_string: "or"`, "apples, oranges, peaches, or plums"},
+	{`"a,b" | split: "," | array_to_sentence_string: "or"`, "a or b"},
+
+	{`products | map_exp: "item", "item.price | times: 2" | join`, "20 5 0"},
+	{`products | map_exp: "p", "p.title | upcase" | join`, "A B C"},
+	{`products | map_exp: "p", "p.price" | join`, "10 2.5"},
+	{`products | map_exp: "p", "p.price | default: 'n/a'" | join`, "10 2.5 n/a"},
+	{`products | map_exp: "p", "p.price > 5" | join`, "true false false"},
+	{`empty_array | map_exp: "p", "p.price" | size`, 0},
+
+	{`posts | sort_by: "p", "p.meta.rank" | map: "title" | join`, "n a b c"},
+	{`posts | sort_by: "p", "p.title" | map: "title" | join`, "a b c n"},
+	{`posts | sort_by: "p", "p.meta.rank | times: -1" | map: "title" | join`, "c b a n"},
+	{`posts | group_by_exp: "p", "p.tag" | map: "name" | join: ","`, "x,y,"},
+	{`posts | group_by_exp: "p", "p.tag" | map: "size" | join`, "2 1 1"},
+	{`posts | group_by_exp: "p", "p.tag" | map: "items" | first | map: "title" | join`, "c b"},
+	{`posts | group_by_exp: "p", "p.meta.rank > 1" | map: "name" | join`, "true false"},
+	{`empty_array | group_by_exp: "p", "p.tag" | size`, 0},
+
 	{`map_slice_has_nil | compact | join`, `a b`},
 	{`map_slice_2 | first`, `b`},
 	{`map_slice_2 | last`, `a`},
@@ -69,6 +165,26 @@ var filterTests = []struct {
 	{`article.published_at | date`, "Fri, Jul 17, 15"},
 	{`article.published_at | date: "%a, %b %d, %y"`, "Fri, Jul 17, 15"},
 	{`article.published_at | date: "%Y"`, "2015"},
+	{`article.published_at | date_to_xmlschema`, "2015-07-17T15:04:05Z"},
+	{`article.published_at | date_to_rfc822`, "Fri, 17 Jul 2015 15:04:05 +0000"},
+	{`article.published_at | date_to_string`, "17 Jul 2015"},
+	{`article.published_at | date_to_string: "ordinal"`, "17th Jul 2015"},
+	{`article.published_at | date_to_string: "ordinal", "US"`, "Jul 17th, 2015"},
+	{`article.published_at | date_to_long_string`, "17 July 2015"},
+	{`article.published_at | date_to_long_string: "ordinal"`, "17th July 2015"},
+	{`article.published_at | date_to_long_string: "ordinal", "US"`, "July 17th, 2015"},
+	{`"2015-07-01T01:02:03Z" | date_to_xmlschema`, "2015-07-01T01:02:03Z"},
+	{`"2015-07-02T01:02:03Z" | date_to_string: "ordinal"`, "2nd Jul 2015"},
+	{`"2015-07-03T01:02:03Z" | date_to_string: "ordinal"`, "3rd Jul 2015"},
+	{`"2015-07-11T01:02:03Z" | date_to_string: "ordinal"`, "11th Jul 2015"},
+	{`"2015-07-21T01:02:03Z" | date_to_string: "ordinal"`, "21st Jul 2015"},
+	{`article.published_at < article.updated_at`, true},
+	{`article.published_at > article.updated_at`, false},
+	{`article.published_at == article.published_at`, true},
+	{`article.published_at <= "2015-07-17T15:04:05Z"`, true},
+	{`article.published_at < "2015-01-01"`, false},
+	{`article.published_at < "now"`, true},
+	{`article.published_at < "not a date"`, false},
 	{`"2017-02-08 19:00:00 -05:00" | date`, "Wed, Feb 08, 17"},
 	{`"2017-05-04 08:00:00 -04:00" | date: "%b %d, %Y"`, "May 04, 2017"},
 	{`"2017-02-08 09:00:00" | date: "%H:%M"`, "09:00"},
@@ -79,6 +195,10 @@ var filterTests = []struct {
 	{`"March 14, 2016" | date: "%b %d, %y"`, "Mar 14, 16"},
 	{`"2017-07-09" | date: "%d/%m"`, "09/07"},
 	{`"2017-07-09" | date: "%e/%m"`, " 9/07"},
+	{`nil | date: "%Y"`, ""},
+	{`undefined | date`, ""},
+	{`zero_time | date: "%Y"`, ""},
+	{`zero_time | date`, ""},
 	{`"2017-07-09" | date: "%-d/%-m"`, "9/7"},
 
 	// sequence (array or string) filters
@@ -88,14 +208,54 @@ var filterTests = []struct {
 	// string filters
 	{`"Take my protein pills and put my helmet on" | replace: "my", "your"`, "Take your protein pills and put your helmet on"},
 	{`"Take my protein pills and put my helmet on" | replace_first: "my", "your"`, "Take your protein pills and put my helmet on"},
+	{`"2024-01-15" | regex_replace: '(\d+)-(\d+)-(\d+)', '$3/$2/$1'`, "15/01/2024"},
+	{`"a1b22c333" | regex_replace: '[0-9]+', '#'`, "a#b#c#"},
+	{`"John Smith" | regex_replace: '(?P<first>\w+) (?P<last>\w+)', '${last}, ${first}'`, "Smith, John"},
+	{`"a.b" | regex_replace: '.', '-'`, "---"},
+	{`"a.b" | replace: '.', '-'`, "a-b"},
+	{`"abc" | regex_replace: 'x', '-'`, "abc"},
+	{`"Order 66 shipped in 3 boxes" | match: '[0-9]+'`, "66"},
+	{`"total: 42 USD" | match: 'total: ([0-9]+)'`, "42"},
+	{`"no digits" | match: '[0-9]+'`, nil},
+	{`"Order 66 shipped in 3 boxes" | scan: '[0-9]+' | join: ","`, "66,3"},
+	{`"a=1, b=2" | scan: '(\w)=\d' | join: ","`, "a,b"},
+	{`"a=1, b=2" | scan: '(\w)=(\d)' | map: "last" | join: ","`, "1,2"},
+	{`"no digits" | scan: '[0-9]+' | size`, 0},
 	{`"/my/fancy/url" | append: ".html"`, "/my/fancy/url.html"},
 	{`"website.com" | append: "/index.html"`, "website.com/index.html"},
 	{`"title" | capitalize`, "Title"},
 	{`"my great title" | capitalize`, "My great title"},
 	{`"" | capitalize`, ""},
+	{`"hello world" | titleize`, "Hello World"},
+	{`"hello world" | capitalize`, "Hello world"},
+	{`"my great title" | titleize`, "My Great Title"},
+	{`"Hello World" | titleize`, "Hello World"},
+	{`"an HTML primer" | titleize`, "An HTML Primer"},
+	{`"x-ray vision" | titleize`, "X-Ray Vision"},
+	{`"x-ray vision" | capitalize`, "X-ray vision"},
+	{`"don't  stop" | titleize`, "Don't  Stop"},
+	{`"élan vital" | titleize`, "Élan Vital"},
+	{`"" | titleize`, ""},
 	{`"Parker Moore" | downcase`, "parker moore"},
 	{`"Have you read 'James & the Giant Peach'?" | escape`, "Have you read &#39;James &amp; the Giant Peach&#39;?"},
 	{`"1 < 2 & 3" | escape_once`, "1 &lt; 2 &amp; 3"},
+	{`"100% M & Ms!!!" | handleize`, "100-m-ms"},
+	{`"100% M & Ms!!!" | handle`, "100-m-ms"},
+	{`"Hello, World" | handleize`, "hello-world"},
+	{`"a -- b__c..d" | handleize`, "a-b-c-d"},
+	{`"--Leading and trailing!--" | handleize`, "leading-and-trailing"},
+	{`"Crème Brûlée" | handleize`, "crme-brle"},
+	{`"!!!" | handleize`, ""},
+	{`"The _config.yml file!" | slugify`, "the-config-yml-file"},
+	{`"The _config.yml file!" | slugify: "default"`, "the-config-yml-file"},
+	{`"The _config.yml file?" | slugify: "pretty"`, "the-_config.yml-file"},
+	{`"Q&A (part 1): what's @new?" | slugify: "pretty"`, "q&a-(part-1)-what's-@new"},
+	{`"The _config.yml file?" | slugify: "raw"`, "the-_config.yml-file?"},
+	{`"The _config.yml file?" | slugify: "none"`, "The _config.yml file?"},
+	{`"Crème Brûlée!" | slugify`, "crème-brûlée"},
+	{`"Crème Brûlée!" | slugify: "ascii"`, "cr-me-br-l-e"},
+	{`"Crème Brûlée!" | slugify: "latin"`, "cr-me-br-l-e"},
+	{`"The _config.yml file!" | slugify: "unknown"`, "the-config-yml-file"},
 	{`string_with_newlines | newline_to_br`, "<br />Hello<br />there<br />"},
 	{`"1 &lt; 2 &amp; 3" | escape_once`, "1 &lt; 2 &amp; 3"},
 	{`"apples, oranges, and bananas" | prepend: "Some fruit: "`, "Some fruit: apples, oranges, and bananas"},
@@ -110,6 +270,22 @@ Liquid" | slice: 0`, "L"},
 	{`"Liquid
 Liquid" | slice: 2, 4`, "quid"},
 	{`"Liquid" | slice: -3, 2`, "ui"},
+	{`"Liquid" | slice: -2`, "i"},
+	{`"Liquid" | slice: -1`, "d"},
+	{`"Liquid" | slice: -6`, "L"},
+	{`"Liquid" | slice: -7`, ""},
+	{`"Liquid" | slice: -10, 3`, ""},
+	{`"Liquid" | slice: -2, 10`, "id"},
+	{`"Liquid" | slice: 6`, ""},
+	{`"Liquid" | slice: 10`, ""},
+	{`"Liquid" | slice: 2, -1`, ""},
+	{`"" | slice: -1`, ""},
+	{`"Liquid" | substring: 2, 5`, "quid"},
+	{`"Liquid" | substring: -3, 2`, "ui"},
+	{`"日本語テキスト" | slice: 1, 3`, "本語テ"},
+	{`"日本語テキスト" | substring: 1, 3`, "本語テ"},
+	{`"日本語テキスト" | slice: -2`, "ス"},
+	{`"日本語テキスト" | substring: -2`, "ス"},
 
 	{`"a/b/c" | split: '/' | join: '-'`, "a-b-c"},
 	{`"a/b/" | split: '/' | join: '-'`, "a-b"},
@@ -122,6 +298,15 @@ Liquid" | slice: 2, 4`, "quid"},
 	{`"/" | split: '/' | join: '-'`, ""},
 	{`"a.b" | split: '.' | join: '-'`, "a-b"},
 	{`"a..b" | split: '.' | join: '-'`, "a--b"},
+	{`"a,b," | split: "," | size`, 2},
+	{`"a,b,,," | split: "," | join: "-"`, "a-b"},
+	{`",a,b" | split: "," | size`, 3},
+	{`",,a,,b,," | split: "," | join: "-"`, "--a--b"},
+	{`",," | split: "," | size`, 0},
+	{`"" | split: "," | size`, 0},
+	{`"abc" | split: "" | join: "-"`, "a-b-c"},
+	{`"  a  b " | split: " " | join: "-"`, "a-b"},
+	{`" " | split: " " | size`, 0},
 	{"'a.\t.b' | split: '.' | join: '-'", "a-\t-b"},
 	{`"a b" | split: ' ' | join: '-'`, "a-b"},
 	{`"a  b" | split: ' ' | join: '-'`, "a-b"},
@@ -129,11 +314,30 @@ Liquid" | slice: 2, 4`, "quid"},
 
 	{`"Have <em>you</em> read <strong>Ulysses</strong>?" | strip_html`, "Have you read Ulysses?"},
 	{`string_with_newlines | strip_newlines`, "Hellothere"},
+	{"'  a\n\n  b ' | normalize_whitespace", "a b"},
+	{"'a\t\tb \r\n c' | normalize_whitespace", "a b c"},
+	{"' \t\n ' | normalize_whitespace", ""},
+	{`string_with_newlines | normalize_whitespace`, "Hello there"},
+	{`"Have <em>you</em>  read <strong>Ulysses</strong>?" | strip_html | strip_whitespace`, "Have you read Ulysses?"},
 
 	{`"Ground control to Major Tom." | truncate: 20`, "Ground control to..."},
 	{`"Ground control to Major Tom." | truncate: 25, ", and so on"`, "Ground control, and so on"},
 	{`"Ground control to Major Tom." | truncate: 20, ""`, "Ground control to Ma"},
 	{`"Ground" | truncate: 20`, "Ground"},
+	{`'"He said --yes..."' | smartify`, "“He said —yes…”"},
+	{`"'Don't,' she said." | smartify`, "‘Don’t,’ she said."},
+	{`'It’s the 90s ("roaring")' | smartify`, "It’s the 90s (“roaring”)"},
+	{`"It's the '90s" | smartify`, "It’s the ’90s"},
+	{`"a -- b --- c" | smartify`, "a — b — c"},
+	{`"Wait... what?" | smartify`, "Wait… what?"},
+	{`"plain" | smartify`, "plain"},
+	{`"Salt &amp; pepper" | truncate: 10`, "Salt &a..."},
+	{`"Salt &amp; pepper" | truncate: 10, "...", true`, "Salt ..."},
+	{`"Salt &amp; pepper" | truncate: 13, "...", true`, "Salt &amp;..."},
+	{`"Salt &#38; pepper" | truncate: 12, "...", true`, "Salt ..."},
+	{`"Salt & pepper and more" | truncate: 10, "...", true`, "Salt & ..."},
+	{`"a &amp b &amp; c d e f" | truncate: 10, "...", true`, "a &amp ..."},
+	{`"Salt &amp;" | truncate: 20, "...", true`, "Salt &amp;"},
 	{`"Ground control to Major Tom." | truncatewords: 3`, "Ground control to..."},
 	{`"Ground control to Major Tom." | truncatewords: 3, "--"`, "Ground control to--"},
 	{`"Ground control to Major Tom." | truncatewords: 3, ""`, "Ground control to"},
@@ -147,24 +351,109 @@ Liquid" | slice: 2, 4`, "quid"},
 	{`"          So much room for activities!          " | strip`, "So much room for activities!"},
 	{`"          So much room for activities!          " | lstrip`, "So much room for activities!          "},
 	{`"          So much room for activities!          " | rstrip`, "          So much room for activities!"},
+	{`"The quick brown fox jumps over the lazy dog" | word_wrap: 10`, "The quick\nbrown fox\njumps over\nthe lazy\ndog"},
+	{`"The quick brown fox" | word_wrap: 80`, "The quick brown fox"},
+	{`"a supercalifragilistic word" | word_wrap: 10`, "a\nsupercalifragilistic\nword"},
+	{`"supercalifragilistic" | word_wrap: 10`, "supercalifragilistic"},
+	{`"one two" | word_wrap: 7`, "one two"},
+	{`"  one   two  " | word_wrap: 5`, "one\ntwo"},
+	{`"" | word_wrap: 10`, ""},
+	{`text | word_wrap: 10`, "First\nparagraph.\n\nSecond\nparagraph."},
+	{`"foo_bar" | camelize`, "FooBar"},
+	{`"foo" | camelize`, "Foo"},
+	{`"foo_bar-baz qux" | camelize`, "FooBarBazQux"},
+	{`"HTML_parser" | camelize`, "HTMLParser"},
+	{`"_foo__bar_" | camelize`, "FooBar"},
+	{`"FooBar" | underscore`, "foo_bar"},
+	{`"fooBar" | underscore`, "foo_bar"},
+	{`"HTMLParser" | underscore`, "html_parser"},
+	{`"parseHTTPResponse2XX" | underscore`, "parse_http_response2_xx"},
+	{`"foo-bar baz" | underscore`, "foo_bar_baz"},
+	{`"foo_bar" | dasherize`, "foo-bar"},
+	{`"FooBar" | dasherize`, "FooBar"},
+	{`"SSLError_code" | dasherize`, "SSLError-code"},
+	{`"5" | pad_left: 3, "0"`, "005"},
+	{`5 | pad_left: 3, "0"`, "005"},
+	{`"5" | pad_right: 3, "0"`, "500"},
+	{`"5" | pad_left: 3`, "  5"},
+	{`"5" | pad_right: 3`, "5  "},
+	{`"12345" | pad_left: 3, "0"`, "12345"},
+	{`"123" | pad_right: 3, "0"`, "123"},
+	{`"5" | pad_left: 6, "ab"`, "ababa5"},
+	{`"5" | pad_right: 6, "ab"`, "5ababa"},
+	{`"é" | pad_left: 3, "·"`, "··é"},
+	{`"5" | pad_left: 3, ""`, "5"},
+	{`"xxhixx" | strip: "x"`, "hi"},
+	{`"xxhixx" | lstrip: "x"`, "hixx"},
+	{`"xxhixx" | rstrip: "x"`, "xxhi"},
+	{`"-_=hi=_-" | strip: "=_-"`, "hi"},
+	{`"  xhix  " | strip: "x"`, "  xhix  "},
+	{`" hi " | strip: " "`, "hi"},
+	{`" hi " | strip: ""`, "hi"},
 
 	{`"%27Stop%21%27+said+Fred" | url_decode`, "'Stop!' said Fred"},
 	{`"john@liquid.com" | url_encode`, "john%40liquid.com"},
 	{`"Tetsuro Takara" | url_encode`, "Tetsuro+Takara"},
+	{`"<p>Health & Love potions</p>" | url_escape`, "%3Cp%3EHealth%20&%20Love%20potions%3C/p%3E"},
+	{`"<p>Health & Love potions</p>" | url_param_escape`, "%3Cp%3EHealth%20%26%20Love%20potions%3C/p%3E"},
+	{`"<p>Health & Love potions</p>" | url_encode`, "%3Cp%3EHealth+%26+Love+potions%3C%2Fp%3E"},
+	{`"/search?q=a+b&page=2#top" | url_escape`, "/search?q=a+b&page=2#top"},
+	{`"a+b=c&d#e" | url_param_escape`, "a%2Bb%3Dc%26d%23e"},
+	{`"john@liquid.com" | url_escape`, "john@liquid.com"},
+	{`"100% café" | url_escape`, "100%25%20caf%C3%A9"},
+	{`"100% café" | url_param_escape`, "100%25%20caf%C3%A9"},
+	{`'"Tom & Jerry" <b>' | xml_escape`, "&quot;Tom &amp; Jerry&quot; &lt;b&gt;"},
+	{`'"Tom & Jerry" <b>' | escape`, "&#34;Tom &amp; Jerry&#34; &lt;b&gt;"},
+	{`"Tom's" | xml_escape`, "Tom&#39;s"},
+	{`"foo, bar; baz?" | cgi_escape`, "foo%2C+bar%3B+baz%3F"},
+	{`"foo, bar; baz?" | url_escape`, "foo,%20bar;%20baz?"},
+	{`"a/b~c_d.e-f" | cgi_escape`, "a%2Fb~c_d.e-f"},
+
+	// encoding filters
+	{`"one two three" | base64_encode`, "b25lIHR3byB0aHJlZQ=="},
+	{`"b25lIHR3byB0aHJlZQ==" | base64_decode`, "one two three"},
+	{`"one two three" | base64_encode | base64_decode`, "one two three"},
+	{`"<<???>>" | base64_encode`, "PDw/Pz8+Pg=="},
+	{`"<<???>>" | base64_url_safe_encode`, "PDw_Pz8-Pg=="},
+	{`"PDw_Pz8-Pg==" | base64_url_safe_decode`, "<<???>>"},
+	{`"<<???>>" | base64_url_safe_encode | base64_url_safe_decode`, "<<???>>"},
+
+	// digest filters
+	{`"Polina" | md5`, "71d0ea493b78a6a33e7a68ae74254017"},
+	{`"Polina" | sha1`, "f429155c2cac473dc23ff4a0a353bd7fb3c1cfe7"},
+	{`"Polina" | sha256`, "2e78aebdaa6e03cd6f6567338006cccb33b2a13d254ef3c4c8b00701e0d8a325"},
+	{`"Polina" | hmac_sha256: "secret_key"`, "e77f660a48bbc88810d5d6c09539efe4ce3910bc8157a18be2d54059bcd4f9de"},
+	{`"" | sha256`, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
 
 	// number filters
-	{`-17 | abs`, 17.0},
-	{`4 | abs`, 4.0},
+	{`-17 | abs`, 17},
+	{`4 | abs`, 4},
+	{`-17.5 | abs`, 17.5},
+	{`4.0 | abs`, 4.0},
 	{`"-19.86" | abs`, 19.86},
+	{`"-19" | abs`, 19},
+	{`"abc" | abs`, 0},
+	{`nil | abs`, 0},
+	{`-17 | abs | divided_by: 2`, 8},
 
 	{`1.2 | ceil`, 2},
 	{`2.0 | ceil`, 2},
 	{`183.357 | ceil`, 184},
 	{`"3.5" | ceil`, 4},
+	{`1.234 | ceil: 1`, 1.3},
+	{`1.234 | ceil: 2`, 1.24},
+	{`-1.234 | ceil: 2`, -1.23},
+	{`1.1 | ceil: 2`, 1.1},
+	{`183.357 | ceil: 0`, 184},
 
 	{`1.2 | floor`, 1},
 	{`2.0 | floor`, 2},
 	{`183.357 | floor`, 183},
+	{`1.234 | floor: 1`, 1.2},
+	{`1.234 | floor: 2`, 1.23},
+	{`-1.234 | floor: 2`, -1.24},
+	{`0.29 | floor: 2`, 0.29},
+	{`183.357 | floor: 0`, 183},
 
 	{`4 | plus: 2`, 6.0},
 	{`183.357 | plus: 12`, 195.357},
@@ -176,6 +465,12 @@ Liquid" | slice: 2, 4`, "quid"},
 	{`3 | times: 2`, 6.0},
 	{`24 | times: 7`, 168.0},
 	{`183.357 | times: 12`, 2200.284},
+	{`3 | times: 0`, 0.0},
+	{`"3" | times: 2`, 6.0},
+	{`"ab" | times: 3`, "ababab"},
+	{`"ab" | times: 1`, "ab"},
+	{`"ab" | times: 0`, ""},
+	{`"" | times: 3`, ""},
 
 	{`3 | modulo: 2`, 1.0},
 	{`24 | modulo: 7`, 3.0},
@@ -186,6 +481,16 @@ Liquid" | slice: 2, 4`, "quid"},
 	{`20 | divided_by: 7`, 2},
 	{`20 | divided_by: 7.0`, 2.857142857142857},
 	{`20 | divided_by: 's'`, nil},
+	{`20 | divided_by: "7"`, 2},
+	{`"20" | divided_by: "7.0"`, 2.857142857142857},
+	{`"20" | divided_by: " 4 "`, 5},
+
+	// numeric strings are converted where a filter takes a number
+	{`"5" | plus: 2 | times: 3`, 21.0},
+	{`" 5 " | plus: "2" | times: "3"`, 21.0},
+	{`"5" | minus: "0.5" | times: 2`, 9.0},
+	{`"7" | modulo: "4"`, 3.0},
+	{`"5" | plus: 2 | divided_by: 2`, 3},
 
 	{`1.2 | round`, 1.0},
 	{`2.7 | round`, 3.0},
@@ -198,13 +503,34 @@ Liquid" | slice: 2, 4`, "quid"},
 	{`"1" | type`, `string`},
 }
 
+var filterErrorTests = []struct{ in, expected string }{
+	{`"not base64!" | base64_decode`, "invalid base64"},
+	{`"PDw/Pz8+Pg==" | base64_url_safe_decode`, "invalid base64"},
+	{`20 | divided_by: 0`, "divided by 0"},
+	{`20 | divided_by: "0"`, "divided by 0"},
+	{`"ab" | times: -1`, "negative argument"},
+	{`"x" | times: 3000000000000000000`, "past the limit"},
+	{`"abc" | times: 1000000000`, "past the limit"},
+	{`"abc" | regex_replace: '(', 'x'`, "invalid regular expression"},
+	{`"abc" | match: '['`, "invalid regular expression"},
+	{`"abc" | scan: '*'`, "invalid regular expression"},
+	{`"not a date" | date: "%Y"`, "can't convert"},
+	{`"2017-13-45" | date`, "can't convert"},
+}
+
 var filterTestBindings = map[string]interface{}{
+	"text":            "First paragraph.\n\nSecond paragraph.",
 	"empty_array":     []interface{}{},
 	"empty_map":       map[string]interface{}{},
 	"empty_map_slice": yaml.MapSlice{},
 	"map": map[string]interface{}{
 		"a": 1,
 	},
+	"multi_key_map": map[string]interface{}{
+		"c": 3,
+		"a": 1,
+		"b": 2,
+	},
 	"map_slice_2":       yaml.MapSlice{{Key: 1, Value: "b"}, {Key: 2, Value: "a"}},
 	"map_slice_dup":     yaml.MapSlice{{Key: 1, Value: "a"}, {Key: 2, Value: "a"}, {Key: 3, Value: "b"}},
 	"map_slice_has_nil": yaml.MapSlice{{Key: 1, Value: "a"}, {Key: 2, Value: nil}, {Key: 3, Value: "b"}},
@@ -224,15 +550,40 @@ var filterTestBindings = map[string]interface{}{
 		{"weight": 3},
 		{"weight": nil},
 	},
+	"nested": map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": []interface{}{"x", map[string]interface{}{"c": "y"}},
+		},
+	},
+	"posts": []map[string]interface{}{
+		{"title": "c", "tag": "x", "meta": map[string]interface{}{"rank": 3}},
+		{"title": "a", "tag": "y", "meta": map[string]interface{}{"rank": 1}},
+		{"title": "n"},
+		{"title": "b", "tag": "x", "meta": map[string]interface{}{"rank": 2}},
+	},
+	"books": []map[string]interface{}{
+		{"title": "a", "author": map[string]interface{}{"name": "Sam"}},
+		{"title": "b", "author": map[string]interface{}{"name": "Kim", "address": map[string]interface{}{"city": "Paris"}}},
+		{"title": "c", "author": map[string]interface{}{"name": "Sam"}},
+		{"title": "d"},
+		{"title": "e", "author": map[string]interface{}{}},
+	},
+	"products": []map[string]interface{}{
+		{"title": "a", "price": 10},
+		{"title": "b", "price": 2.5},
+		{"title": "c"},
+	},
 	"string_with_newlines": "\nHello\nthere\n",
 	"dup_ints":             []int{1, 2, 1, 3},
 	"dup_strings":          []string{"one", "two", "one", "three"},
+	"zero_time":            time.Time{},
 
 	// for examples from liquid docs
 	"animals": []string{"zebra", "octopus", "giraffe", "Sally Snake"},
 	"fruits":  []string{"apples", "oranges", "peaches", "plums"},
 	"article": map[string]interface{}{
 		"published_at": timeMustParse("2015-07-17T15:04:05Z"),
+		"updated_at":   timeMustParse("2015-07-18T10:00:00Z"),
 	},
 	"page": map[string]interface{}{
 		"title": "Introduction",
@@ -278,6 +629,160 @@ func TestFilters(t *testing.T) {
 	}
 }
 
+func TestAddStandardFilters(t *testing.T) {
+	cfg := expressions.NewConfig()
+	AddStandardFilters(&cfg)
+	for _, name := range []string{"append", "date", "default", "join", "map", "upcase"} {
+		require.Truef(t, cfg.HasFilter(name), name)
+	}
+	require.False(t, cfg.HasFilter("custom"))
+	names := cfg.FilterNames()
+	require.True(t, sort.StringsAreSorted(names))
+	require.Contains(t, names, "upcase")
+
+	cfg.AddFilter("custom", func(s string) string { return s })
+	require.True(t, cfg.HasFilter("custom"))
+	require.Contains(t, cfg.FilterNames(), "custom")
+	require.Len(t, cfg.FilterNames(), len(names)+1)
+}
+
+func TestFilters_autoescape(t *testing.T) {
+	cfg := expressions.NewConfig()
+	AddStandardFilters(&cfg)
+	bindings := map[string]interface{}{"items": []string{"<a>", "<b>", "&c"}}
+	tests := []struct {
+		in, raw, escaped string
+	}{
+		{`items | join: ", "`, "<a>, <b>, &c", "&lt;a&gt;, &lt;b&gt;, &amp;c"},
+		{`items | join: "<br>"`, "<a><br><b><br>&c", "&lt;a&gt;<br>&lt;b&gt;<br>&amp;c"},
+		{`items | join`, "<a> <b> &c", "&lt;a&gt; &lt;b&gt; &amp;c"},
+	}
+	for _, test := range tests {
+		cfg.Autoescape = false
+		value, err := expressions.EvaluateString(test.in, expressions.NewContext(bindings, cfg))
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.raw, value, test.in)
+
+		cfg.Autoescape = true
+		value, err = expressions.EvaluateString(test.in, expressions.NewContext(bindings, cfg))
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.escaped, value, test.in)
+	}
+}
+
+func TestFilters_markdownify(t *testing.T) {
+	cfg := expressions.NewConfig()
+	AddStandardFilters(&cfg)
+	cfg.Markdown = func(s string) string {
+		return "<p>" + strings.Trim(s, "*") + "</p>"
+	}
+	ctx := expressions.NewContext(map[string]interface{}{}, cfg)
+	value, err := expressions.EvaluateString(`"*text*" | markdownify`, ctx)
+	require.NoError(t, err)
+	require.Equal(t, "<p>text</p>", value)
+
+	// the converter is looked up when the filter is applied, from the
+	// configuration of the context
+	cfg.Markdown = strings.ToUpper
+	ctx = expressions.NewContext(map[string]interface{}{}, cfg)
+	value, err = expressions.EvaluateString(`"*text*" | markdownify`, ctx)
+	require.NoError(t, err)
+	require.Equal(t, "*TEXT*", value)
+
+	// without a converter, markdownify returns its input
+	plain := expressions.NewConfig()
+	AddStandardFilters(&plain)
+	ctx = expressions.NewContext(map[string]interface{}{}, plain)
+	value, err = expressions.EvaluateString(`"*text*" | markdownify`, ctx)
+	require.NoError(t, err)
+	require.Equal(t, "*text*", value)
+}
+
+func TestFilters_random(t *testing.T) {
+	cfg := expressions.NewConfig()
+	AddStandardFilters(&cfg)
+	bindings := map[string]interface{}{
+		"a":     []int{1, 2, 3, 4, 5},
+		"empty": []int{},
+	}
+	tests := []struct {
+		in       string
+		expected interface{}
+	}{
+		{`a | shuffle | join`, "3 1 2 5 4"},
+		{`a | sample`, 2},
+		{`a | sample: 2 | join`, "3 1"},
+		{`a | sample: 5 | join`, "3 1 2 5 4"},
+		{`a | sample: 10 | join`, "3 1 2 5 4"},
+		{`a | sample: 0 | size`, 0},
+		{`a | shuffle | size`, 5},
+		{`empty | sample`, nil},
+		{`empty | shuffle | size`, 0},
+	}
+	for _, test := range tests {
+		cfg.Rand = rand.New(rand.NewSource(1)) // nolint: gosec
+		ctx := expressions.NewContext(bindings, cfg)
+		value, err := expressions.EvaluateString(test.in, ctx)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, value, test.in)
+	}
+}
+
+func TestFilters_cyclic(t *testing.T) {
+	cfg := expressions.NewConfig()
+	AddStandardFilters(&cfg)
+	m := map[string]interface{}{"a": 1}
+	m["self"] = m
+	s := []interface{}{"a", nil}
+	s[1] = s
+	shared := []interface{}{1}
+	ctx := expressions.NewContext(map[string]interface{}{
+		"m":      m,
+		"s":      s,
+		"shared": map[string]interface{}{"x": shared, "y": shared},
+	}, cfg)
+	tests := []struct{ in, expected string }{
+		{`m | inspect`, `{"a":1,"self":"..."}`},
+		{`m | json`, `{"a":1,"self":"..."}`},
+		{`s | inspect`, `["a","..."]`},
+		{`shared | inspect`, `{"x":[1],"y":[1]}`},
+	}
+	for _, test := range tests {
+		value, err := expressions.EvaluateString(test.in, ctx)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, fmt.Sprint(value), test.in)
+	}
+}
+
+func TestFilters_errors(t *testing.T) {
+	cfg := expressions.NewConfig()
+	AddStandardFilters(&cfg)
+	context := expressions.NewContext(filterTestBindings, cfg)
+
+	for i, test := range filterErrorTests {
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			_, err := expressions.EvaluateString(test.in, context)
+			require.Errorf(t, err, test.in)
+			require.Containsf(t, err.Error(), test.expected, test.in)
+		})
+	}
+}
+
+func TestFilters_times_limit(t *testing.T) {
+	cfg := expressions.NewConfig()
+	AddStandardFilters(&cfg)
+	cfg.MaxOutputBytes = 10
+	ctx := expressions.NewContext(map[string]interface{}{}, cfg)
+
+	value, err := expressions.EvaluateString(`"ab" | times: 5`, ctx)
+	require.NoError(t, err)
+	require.Equal(t, "ababababab", value)
+
+	_, err = expressions.EvaluateString(`"ab" | times: 6`, ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "past the limit of 10 bytes")
+}
+
 func timeMustParse(s string) time.Time {
 	t, err := time.Parse(time.RFC3339, s)
 	if err != nil {
@@ -8,6 +8,7 @@ The liquid package itself is versioned in gopkg.in. Subpackages have no compatib
 package liquid
 
 import (
+	"github.com/osteele/liquid/expressions"
 	"github.com/osteele/liquid/render"
 	"github.com/osteele/liquid/tags"
 )
@@ -33,6 +34,42 @@ type SourceError interface {
 	LineNumber() int
 }
 
+// ErrUndefinedFilter is the cause of a RenderError for a filter that isn't
+// defined. Test for it with errors.Is.
+var ErrUndefinedFilter = expressions.ErrUndefinedFilter
+
+// A ParseError is a SourceError from parsing a template.
+type ParseError struct{ SourceError }
+
+// Unwrap returns the underlying SourceError.
+func (e *ParseError) Unwrap() error { return e.SourceError }
+
+// A RenderError is a SourceError from rendering a template.
+type RenderError struct{ SourceError }
+
+// Unwrap returns the underlying SourceError.
+func (e *RenderError) Unwrap() error { return e.SourceError }
+
+// An UndefinedVariableError is the cause of a RenderError for an undefined
+// variable, when Engine.StrictVariables is set.
+type UndefinedVariableError = render.UndefinedVariableError
+
+// A Config is the configuration of an Engine: its tags, filters, and other
+// settings. See Engine.Config and Template.Validate.
+type Config = render.Config
+
+// A FilterContext is the optional first parameter of a filter that reads the
+// variables of the template that applies it. See Engine.RegisterFilter.
+type FilterContext = expressions.FilterContext
+
+// Assets holds the contents of a template's {% stylesheet %} and
+// {% javascript %} blocks. See Template.Assets.
+type Assets = tags.Assets
+
+// A FilterArgumentError is the cause of a RenderError for a filter input or
+// argument that can't be converted to the type the filter requires.
+type FilterArgumentError = expressions.FilterArgumentError
+
 // IterationKeyedMap returns a map whose {% for %} tag iteration values are its keys, instead of [key, value] pairs.
 // Use this to create a Go map with the semantics of a Ruby struct drop.
 func IterationKeyedMap(m map[string]interface{}) tags.IterationKeyedMap {
@@ -7,9 +7,27 @@ type Config struct {
 	expressions.Config
 	Grammar Grammar
 	Delims  []string
+
+	// TrimTagNewlines removes the first newline after a tag, such as the one
+	// that ends a line that contains only {% if x %}, as though the tag were
+	// written {% if x -%} but without trimming other whitespace.
+	TrimTagNewlines bool
 }
 
 // NewConfig creates a parser Config.
 func NewConfig(g Grammar) Config {
-	return Config{Grammar: g}
+	return Config{Config: expressions.NewConfig(), Grammar: g}
+}
+
+// SetTagDelimiters sets the delimiters of objects and tags, for example to
+// "[[", "]]", "[%", and "%]". An empty string stands for the corresponding
+// default: "{{", "}}", "{%", or "%}".
+func (c *Config) SetTagDelimiters(objectLeft, objectRight, tagLeft, tagRight string) {
+	delims := []string{objectLeft, objectRight, tagLeft, tagRight}
+	for i, d := range []string{"{{", "}}", "{%", "%}"} {
+		if delims[i] == "" {
+			delims[i] = d
+		}
+	}
+	c.Delims = delims
 }
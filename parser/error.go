@@ -52,6 +52,11 @@ func (e *sourceLocError) Cause() error {
 	return e.cause
 }
 
+// Unwrap returns the cause, so that errors.Is and errors.As can inspect it.
+func (e *sourceLocError) Unwrap() error {
+	return e.cause
+}
+
 func (e *sourceLocError) Path() string {
 	return e.Pathname
 }
@@ -0,0 +1,86 @@
+package parser
+
+import "strings"
+
+// Format returns a normalized version of a template source. Objects and tags
+// are written with a single space inside their delimiters, for example
+// {{ x }} and {% if x %}. Text, comments, the contents of raw blocks, and the
+// lines of {% liquid %} tags are reproduced exactly.
+//
+// delims are the object and tag delimiters, as for Scan. If delims is nil,
+// the standard delimiters are used.
+func Format(source string, delims []string) string {
+	if len(delims) != 4 {
+		delims = []string{"{{", "}}", "{%", "%}"}
+	}
+	var (
+		buf   strings.Builder
+		inRaw = false
+	)
+	for _, tok := range Scan(source, SourceLoc{}, delims) {
+		switch {
+		case inRaw:
+			if tok.Type == TagTokenType && tok.Name == "endraw" {
+				inRaw = false
+				buf.WriteString(formatToken(tok, delims[2], delims[3]))
+			} else {
+				buf.WriteString(tok.Source)
+			}
+		case tok.Type == ObjTokenType:
+			buf.WriteString(formatToken(tok, delims[0], delims[1]))
+		case tok.Type == TagTokenType:
+			inRaw = tok.Name == "raw"
+			buf.WriteString(formatToken(tok, delims[2], delims[3]))
+		default:
+			buf.WriteString(tok.Source)
+		}
+	}
+	return buf.String()
+}
+
+// formatToken writes an object or tag token with canonical spacing. Tokens
+// that weren't written with their own delimiters, such as the lines of a
+// {% liquid %} tag, are returned unchanged.
+func formatToken(tok Token, left, right string) string {
+	s := tok.Source
+	if !strings.HasPrefix(s, left) || !strings.HasSuffix(s, right) {
+		return s
+	}
+	inner := s[len(left) : len(s)-len(right)]
+	if tok.TrimLeft {
+		inner = strings.TrimPrefix(inner, "-")
+	}
+	if tok.TrimRight {
+		inner = strings.TrimSuffix(inner, "-")
+	}
+	inner = strings.TrimSpace(inner)
+	if tok.Type == TagTokenType {
+		if !strings.HasPrefix(inner, tok.Name) {
+			return s
+		}
+		inner = strings.TrimSpace(inner[len(tok.Name):])
+	}
+	args := strings.TrimSpace(tok.Args)
+	if inner != args {
+		return s
+	}
+	var buf strings.Builder
+	buf.WriteString(left)
+	if tok.TrimLeft {
+		buf.WriteByte('-')
+	}
+	buf.WriteByte(' ')
+	if tok.Type == TagTokenType {
+		buf.WriteString(tok.Name)
+		if args != "" {
+			buf.WriteByte(' ')
+		}
+	}
+	buf.WriteString(args)
+	buf.WriteByte(' ')
+	if tok.TrimRight {
+		buf.WriteByte('-')
+	}
+	buf.WriteString(right)
+	return buf.String()
+}
@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var formatTests = []struct{ in, expected string }{
+	{`{{x}}`, `{{ x }}`},
+	{`{{   x | plus: 1   }}`, `{{ x | plus: 1 }}`},
+	{`{%if x%}a{%endif%}`, `{% if x %}a{% endif %}`},
+	{"{%  if x\n%}", `{% if x %}`},
+	{`{%-if x-%}{{-x-}}`, `{%- if x -%}{{- x -}}`},
+	{`{%   else   %}`, `{% else %}`},
+	{"  text\t\n{{x}}  ", "  text\t\n{{ x }}  "},
+	{`{% if x == "a  b" %}`, `{% if x == "a  b" %}`},
+	{`{%#  comment  %}`, `{%#  comment  %}`},
+	{`{#comment#}`, `{#comment#}`},
+	{`{%raw%}{{x}}{%  endraw%}`, `{% raw %}{{x}}{% endraw %}`},
+	{"{%- liquid\n  echo x\n  assign y = 1\n-%}", "{%- liquid\n  echo x\n  assign y = 1\n-%}"},
+	{`{% liquid echo x %}`, `{% liquid echo x %}`},
+	{`{{ x`, `{{ x`},
+}
+
+func TestFormat(t *testing.T) {
+	for i, test := range formatTests {
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			require.Equalf(t, test.expected, Format(test.in, nil), test.in)
+		})
+	}
+}
+
+func TestFormat_delims(t *testing.T) {
+	delims := []string{"<<", ">>", "<%", "%>"}
+	require.Equal(t, "<< x >><% if x %>{{x}}", Format("<<x>><%if x%>{{x}}", delims))
+}
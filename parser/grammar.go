@@ -12,6 +12,9 @@ type BlockSyntax interface {
 	IsBlockEnd() bool
 	IsBlockStart() bool
 	IsClause() bool
+	// IsOpaque reports whether the body of a block is text, that the parser
+	// doesn't scan for tags and objects, up to the first matching end tag.
+	IsOpaque() bool
 	ParentTags() []string
 	RequiresParent() bool
 	TagName() string
@@ -4,6 +4,7 @@ package parser
 import (
 	"fmt"
 	"strings"
+	"unicode"
 
 	"github.com/osteele/liquid/expressions"
 )
@@ -11,9 +12,62 @@ import (
 // Parse parses a source template. It returns an AST root, that can be compiled and evaluated.
 func (c Config) Parse(source string, loc SourceLoc) (ASTNode, Error) {
 	tokens := Scan(source, loc, c.Delims)
+	trimWhitespace(tokens, c.Grammar, c.TrimTagNewlines)
 	return c.parseTokens(tokens)
 }
 
+// trimWhitespace applies whitespace control. It removes the whitespace from
+// the end of a text token that precedes a token such as {%- tag %} or
+// {{- expr }}, and from the start of a text token that follows one such as
+// {% tag -%}. This applies to every tag, including the start, clause and end
+// tags of blocks. The markers of tokens inside a raw or other opaque block are
+// ignored.
+//
+// If newlines is true, it also removes a single newline from the start of a
+// text token that follows a tag.
+func trimWhitespace(tokens []Token, g Grammar, newlines bool) {
+	endTag := "" // the end tag of the current raw or opaque block, if any
+	active := make([]bool, len(tokens))
+	for i, tok := range tokens {
+		switch {
+		case endTag != "" && tok.Type == TagTokenType && tok.Name == endTag:
+			endTag = ""
+			active[i] = true
+		case endTag != "":
+		case tok.Type == TagTokenType && (tok.Name == "raw" || isOpaqueBlock(g, tok.Name)):
+			endTag = "end" + tok.Name
+			active[i] = true
+		default:
+			active[i] = true
+		}
+	}
+	for i := range tokens {
+		if tokens[i].Type != TextTokenType {
+			continue
+		}
+		if i > 0 && active[i-1] {
+			switch prev := tokens[i-1]; {
+			case prev.TrimRight:
+				tokens[i].Source = strings.TrimLeftFunc(tokens[i].Source, unicode.IsSpace)
+			case newlines && prev.Type != ObjTokenType:
+				s := strings.TrimPrefix(tokens[i].Source, "\r")
+				if strings.HasPrefix(s, "\n") {
+					tokens[i].Source = s[1:]
+				}
+			}
+		}
+		if i+1 < len(tokens) && active[i+1] && tokens[i+1].TrimLeft {
+			tokens[i].Source = strings.TrimRightFunc(tokens[i].Source, unicode.IsSpace)
+		}
+	}
+}
+
+// isOpaqueBlock reports whether name is the start tag of an opaque block.
+func isOpaqueBlock(g Grammar, name string) bool {
+	cs, ok := g.BlockSyntax(name)
+	return ok && cs.IsBlockStart() && cs.IsOpaque()
+}
+
 // Parse creates an AST from a sequence of tokens.
 func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocyclo
 	// a stack of control tag state, for matching nested {%if}{%endif%} etc.
@@ -30,6 +84,7 @@ func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocycl
 		bn        *ASTBlock        // current block node
 		stack     []frame          // stack of blocks
 		rawTag    *ASTRaw          // current raw tag
+		opaque    *ASTText         // body of the current opaque block
 		inComment = false
 		inRaw     = false
 	)
@@ -48,6 +103,13 @@ func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocycl
 			} else {
 				rawTag.Slices = append(rawTag.Slices, tok.Source)
 			}
+		case opaque != nil && (tok.Type != TagTokenType || tok.Name != "end"+bn.Name):
+			// The body of an opaque block is a single text node, that ends at
+			// the block's end tag.
+			if opaque.Source == "" {
+				opaque.SourceLoc = tok.SourceLoc
+			}
+			opaque.Source += tok.Source
 		case tok.Type == ObjTokenType:
 			expr, err := expressions.Parse(tok.Args)
 			if err != nil {
@@ -56,6 +118,8 @@ func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocycl
 			*ap = append(*ap, &ASTObject{tok, expr})
 		case tok.Type == TextTokenType:
 			*ap = append(*ap, &ASTText{Token: tok})
+		case tok.Type == CommentTokenType:
+			// inline comments produce no output
 		case tok.Type == TagTokenType:
 			if cs, ok := g.BlockSyntax(tok.Name); ok {
 				switch {
@@ -79,11 +143,20 @@ func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocycl
 					}
 					push()
 					ap = &bn.Body
+					if cs.IsOpaque() {
+						opaque = &ASTText{Token: Token{Type: TextTokenType}}
+					}
 				case cs.IsClause():
 					n := &ASTBlock{Token: tok, syntax: cs}
 					bn.Clauses = append(bn.Clauses, n)
 					ap = &n.Body
 				case cs.IsBlockEnd():
+					if opaque != nil {
+						if opaque.Source != "" {
+							bn.Body = append(bn.Body, opaque)
+						}
+						opaque = nil
+					}
 					pop := func() {
 						f := stack[len(stack)-1]
 						stack = stack[:len(stack)-1]
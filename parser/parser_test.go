@@ -21,9 +21,10 @@ func (g blockSyntaxFake) CanHaveParent(p BlockSyntax) bool {
 }
 func (g blockSyntaxFake) IsBlockEnd() bool { return strings.HasPrefix(string(g), "end") }
 func (g blockSyntaxFake) IsBlockStart() bool {
-	return g == "for" || g == "if" || g == "unless"
+	return g == "for" || g == "if" || g == "unless" || g == "verbatim"
 }
 func (g blockSyntaxFake) IsClause() bool       { return g == "else" }
+func (g blockSyntaxFake) IsOpaque() bool       { return g == "verbatim" }
 func (g blockSyntaxFake) ParentTags() []string { return []string{"unless"} }
 func (g blockSyntaxFake) RequiresParent() bool { return g == "else" || g.IsBlockEnd() }
 func (g blockSyntaxFake) TagName() string      { return string(g) }
@@ -46,6 +47,7 @@ var parserTests = []struct{ in string }{
 
 	{`{% comment %}{% if true %}{% endcomment %}`},
 	{`{% raw %}{% if true %}{% endraw %}`},
+	{`{% verbatim %}{% if true %}{{ x {% endverbatim %}`},
 }
 
 func TestParseErrors(t *testing.T) {
@@ -68,3 +70,43 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_opaque(t *testing.T) {
+	cfg := Config{Grammar: grammarFake{}}
+	ast, err := cfg.Parse("{% if test %}{% verbatim %}a\n{% for x %}{{ b }}{%- if -%}{% endverbatim %}{% endif %}", SourceLoc{LineNo: 1})
+	require.NoError(t, err)
+	block := ast.(*ASTSeq).Children[0].(*ASTBlock).Body[0].(*ASTBlock)
+	require.Equal(t, "verbatim", block.Name)
+	require.Len(t, block.Body, 1)
+	require.Equal(t, "a\n{% for x %}{{ b }}{%- if -%}", block.Body[0].(*ASTText).Source)
+	require.Equal(t, 1, block.Body[0].SourceLocation().LineNo)
+
+	// an empty body has no nodes
+	ast, err = cfg.Parse(`{% verbatim %}{% endverbatim %}`, SourceLoc{})
+	require.NoError(t, err)
+	require.Empty(t, ast.(*ASTSeq).Children[0].(*ASTBlock).Body)
+
+	_, err = cfg.Parse(`{% verbatim %}{% if true %}{% endif %}`, SourceLoc{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `unterminated "verbatim" block`)
+}
+
+func TestConfig_SetTagDelimiters(t *testing.T) {
+	cfg := Config{Grammar: grammarFake{}}
+	cfg.SetTagDelimiters("[[", "]]", "[%", "%]")
+	require.Equal(t, []string{"[[", "]]", "[%", "%]"}, cfg.Delims)
+	ast, err := cfg.Parse(`[% if test %][[ x ]][% endif %]{{ y }}`, SourceLoc{})
+	require.NoError(t, err)
+	children := ast.(*ASTSeq).Children
+	require.Len(t, children, 2)
+	require.IsType(t, &ASTBlock{}, children[0])
+	require.IsType(t, &ASTText{}, children[1])
+	require.Equal(t, "{{ y }}", children[1].(*ASTText).Source)
+
+	// empty delimiters stand for the defaults
+	cfg.SetTagDelimiters("[[", "]]", "", "")
+	require.Equal(t, []string{"[[", "]]", "{%", "%}"}, cfg.Delims)
+	ast, err = cfg.Parse(`{% if test %}[[ x ]]{% endif %}`, SourceLoc{})
+	require.NoError(t, err)
+	require.Len(t, ast.(*ASTSeq).Children, 1)
+}
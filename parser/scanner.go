@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 // Scan breaks a string into a sequence of Tokens.
@@ -26,29 +27,49 @@ func Scan(data string, loc SourceLoc, delims []string) (tokens []Token) {
 		}
 		source := data[ts:te]
 		switch {
-		case data[ts:ts+len(delims[0])] == delims[0]:
+		case strings.HasPrefix(source, delims[0]):
 			tok := Token{
 				Type:      ObjTokenType,
 				SourceLoc: loc,
 				Source:    source,
 				Args:      data[m[2]:m[3]],
-				TrimLeft:  source[2] == '-',
-				TrimRight: source[len(source)-3] == '-',
+				TrimLeft:  source[len(delims[0])] == '-',
+				TrimRight: source[len(source)-len(delims[1])-1] == '-',
 			}
 			tokens = append(tokens, tok)
-		case data[ts:ts+len(delims[2])] == delims[2]:
+		case strings.HasPrefix(source, delims[2]) && data[m[4]:m[5]] == "#":
+			// {% # comment %} is an inline comment
+			tokens = append(tokens, Token{
+				Type:      CommentTokenType,
+				SourceLoc: loc,
+				Source:    source,
+				TrimLeft:  source[len(delims[2])] == '-',
+				TrimRight: source[len(source)-len(delims[3])-1] == '-',
+			})
+		case strings.HasPrefix(source, delims[2]):
 			tok := Token{
 				Type:      TagTokenType,
 				SourceLoc: loc,
 				Source:    source,
 				Name:      data[m[4]:m[5]],
-				TrimLeft:  source[2] == '-',
-				TrimRight: source[len(source)-3] == '-',
+				TrimLeft:  source[len(delims[2])] == '-',
+				TrimRight: source[len(source)-len(delims[3])-1] == '-',
 			}
 			if m[6] > 0 {
 				tok.Args = data[m[6]:m[7]]
 			}
+			if tok.Name == "liquid" {
+				argsLoc, offset := loc, len(source)
+				if m[6] > 0 {
+					argsLoc.LineNo += strings.Count(data[ts:m[6]], "\n")
+					offset = m[6] - ts
+				}
+				tokens = append(tokens, scanLiquidTag(tok, argsLoc, offset)...)
+				break
+			}
 			tokens = append(tokens, tok)
+		default:
+			tokens = append(tokens, Token{Type: CommentTokenType, SourceLoc: loc, Source: source})
 		}
 		loc.LineNo += strings.Count(source, "\n")
 		p = te
@@ -59,6 +80,50 @@ func Scan(data string, loc SourceLoc, delims []string) (tokens []Token) {
 	return tokens
 }
 
+// scanLiquidTag expands {% liquid %} into a tag token for each line of its
+// arguments. A line "echo expr" is an object, and a line that begins with # is
+// a comment.
+// The arguments begin at loc, and at offset within tok.Source. The source of
+// the tag is divided among the tokens, so that concatenating their sources
+// reproduces it.
+func scanLiquidTag(tok Token, loc SourceLoc, offset int) (tokens []Token) {
+	var starts []int
+	for _, line := range strings.SplitAfter(tok.Args, "\n") {
+		start := offset
+		offset += len(line)
+		line = strings.TrimSpace(line)
+		name, args := line, ""
+		if i := strings.IndexFunc(line, unicode.IsSpace); i >= 0 {
+			name, args = line[:i], strings.TrimSpace(line[i:])
+		}
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			loc.LineNo++
+			continue
+		case name == "echo":
+			tokens = append(tokens, Token{Type: ObjTokenType, SourceLoc: loc, Args: args})
+		default:
+			tokens = append(tokens, Token{Type: TagTokenType, SourceLoc: loc, Name: name, Args: args})
+		}
+		starts = append(starts, start)
+		loc.LineNo++
+	}
+	if len(tokens) == 0 {
+		return []Token{{Type: CommentTokenType, SourceLoc: tok.SourceLoc, Source: tok.Source}}
+	}
+	starts[0] = 0
+	for i := range tokens {
+		end := len(tok.Source)
+		if i+1 < len(tokens) {
+			end = starts[i+1]
+		}
+		tokens[i].Source = tok.Source[starts[i]:end]
+	}
+	tokens[0].TrimLeft = tok.TrimLeft
+	tokens[len(tokens)-1].TrimRight = tok.TrimRight
+	return tokens
+}
+
 func formTokenMatcher(delims []string) *regexp.Regexp {
 	// On ending a tag we need to exclude anything that appears to be ending a tag that's nested
 	// inside the tag. We form the exclusion expression here.
@@ -73,11 +138,20 @@ func formTokenMatcher(delims []string) *regexp.Regexp {
 		}
 	}
 
+	// With the default tag delimiters, a final alternative matches an inline
+	// comment {#…#}, which can span lines and contain other delimiters. Custom
+	// delimiters are often chosen so that braces in the text, as in CSS or
+	// JavaScript, are left alone, so they disable it.
+	inlineComment := ""
+	if delims[2] == "{%" && delims[3] == "%}" {
+		inlineComment = `|\{#(?s:.*?)#\}`
+	}
 	tokenMatcher := regexp.MustCompile(
-		fmt.Sprintf(`%s-?\s*(.+?)\s*-?%s|%s-?\s*(\w+)(?:\s+((?:%v)+?))?\s*-?%s`,
+		fmt.Sprintf(`%s-?\s*(.+?)\s*-?%s|%s-?\s*(\w+|#)(?:\s+((?:%v)+?))?\s*-?%s%s`,
 			// QuoteMeta will escape any of these that are regex commands
 			regexp.QuoteMeta(delims[0]), regexp.QuoteMeta(delims[1]),
 			regexp.QuoteMeta(delims[2]), strings.Join(exclusion, "|"), regexp.QuoteMeta(delims[3]),
+			inlineComment,
 		),
 	)
 
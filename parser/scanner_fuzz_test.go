@@ -0,0 +1,60 @@
+//go:build go1.18
+
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+var scannerFuzzSeeds = []string{
+	"",
+	"text",
+	"{{",
+	"}}",
+	"{%",
+	"%}",
+	"{{ x",
+	"{% if x",
+	"{% if x %}{{ y",
+	"{{ x }",
+	"{% tag %",
+	"{% {{ %}",
+	"{{ {% }}",
+	"{{}}",
+	"{%%}",
+	"{%-%}",
+	"{{-}}",
+	"{{--}}",
+	"{%--%}",
+	"{#",
+	"{# #",
+	"{% # %}",
+	"{% liquid %}",
+	"{% liquid\n%}",
+	"{%- liquid\n echo -%}",
+	"a{{ x }}b{% y z %}c{# d #}e",
+}
+
+func FuzzScan(f *testing.F) {
+	for _, s := range scannerFuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, delims := range [][]string{nil, {"<", ">", "[", "]"}, {"<<<", ">>>", "<%%", "%%>"}} {
+			var buf strings.Builder
+			for _, tok := range Scan(s, SourceLoc{}, delims) {
+				buf.WriteString(tok.Source)
+				if tok.Source == "" {
+					t.Errorf("empty token in %q", s)
+				}
+				if tok.Type != TagTokenType && tok.Type != ObjTokenType && !strings.Contains(s, tok.Source) {
+					t.Errorf("token %q is not in %q", tok.Source, s)
+				}
+			}
+			if buf.String() != s {
+				t.Errorf("token sources %q do not reproduce %q", buf.String(), s)
+			}
+		}
+	})
+}
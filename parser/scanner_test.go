@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -20,6 +21,14 @@ var scannerCountTests = []struct {
 	{`{{ expr arg }}`, 1},
 	{`{{ expr }}{{ expr }}`, 2},
 	{`{{ expr arg }}{{ expr arg }}`, 2},
+	{`{# comment #}`, 1},
+	{`{# comment #}{{ expr }}{# comment #}`, 3},
+	{`{# {% tag %}{{ expr }} #}`, 1},
+	{`{% # comment %}`, 1},
+	{`{%- # comment -%}{{ expr }}`, 2},
+	{`{% liquid %}`, 1},
+	{"{% liquid tag\n tag arg\n\n echo expr %}", 3},
+	{"{% liquid # comment\n tag %}{{ expr }}", 2},
 }
 
 func TestScan(t *testing.T) {
@@ -59,6 +68,26 @@ func TestScan(t *testing.T) {
 	tokens = scan("pre{% tag args %}mid{{ object }}post")
 	require.Equal(t, `[TextTokenType{"pre"} TagTokenType{Tag:"tag", Args:"args"} TextTokenType{"mid"} ObjTokenType{"object"} TextTokenType{"post"}]`, fmt.Sprint(tokens))
 
+	tokens = scan("pre{# comment #}post")
+	require.Equal(t, `[TextTokenType{"pre"} CommentTokenType{"{# comment #}"} TextTokenType{"post"}]`, fmt.Sprint(tokens))
+
+	tokens = scan("{# {{ obj }} {% tag %}\n #}{{ obj }}")
+	require.Len(t, tokens, 2)
+	require.Equal(t, CommentTokenType, tokens[0].Type)
+	require.Equal(t, ObjTokenType, tokens[1].Type)
+	require.Equal(t, 1, tokens[1].SourceLoc.LineNo)
+
+	tokens = scan("{% # a comment %}post")
+	require.Equal(t, `[CommentTokenType{"{% # a comment %}"} TextTokenType{"post"}]`, fmt.Sprint(tokens))
+
+	tokens = scan("{%- liquid\n  assign x = 1\n  # a comment\n  if x\n    echo x | plus: 1\n  endif\n-%}")
+	require.Equal(t, `[TagTokenType{Tag:"assign", Args:"x = 1"} TagTokenType{Tag:"if", Args:"x"} ObjTokenType{"x | plus: 1"} TagTokenType{Tag:"endif", Args:""}]`, fmt.Sprint(tokens))
+	require.Equal(t, 1, tokens[0].SourceLoc.LineNo)
+	require.Equal(t, 3, tokens[1].SourceLoc.LineNo)
+	require.Equal(t, 4, tokens[2].SourceLoc.LineNo)
+	require.True(t, tokens[0].TrimLeft)
+	require.True(t, tokens[3].TrimRight)
+
 	for i, test := range scannerCountTests {
 		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
 			tokens := scan(test.in)
@@ -112,6 +141,8 @@ var scannerCountTestsDelims = []struct {
 	{`OBJECT@LEFT expr arg OBJECT#RIGHT`, 1},
 	{`OBJECT@LEFT expr OBJECT#RIGHTOBJECT@LEFT expr OBJECT#RIGHT`, 2},
 	{`OBJECT@LEFT expr arg OBJECT#RIGHTOBJECT@LEFT expr arg OBJECT#RIGHT`, 2},
+	// {#…#} is only an inline comment with the default tag delimiters
+	{`OBJECT@LEFT expr OBJECT#RIGHT{##}`, 2},
 }
 
 func TestScan_delims(t *testing.T) {
@@ -150,6 +181,9 @@ func TestScan_delims(t *testing.T) {
 	require.Equal(t, "tag", tokens[0].Name)
 	require.Equal(t, "args", tokens[0].Args)
 
+	tokens = scan("color: {#fff#};TAG*LEFT # note TAG!RIGHT")
+	require.Equal(t, `[TextTokenType{"color: {#fff#};"} CommentTokenType{"TAG*LEFT # note TAG!RIGHT"}]`, fmt.Sprint(tokens))
+
 	tokens = scan("preTAG*LEFT tag args TAG!RIGHTmidOBJECT@LEFT object OBJECT#RIGHTpost")
 	require.Equal(t, `[TextTokenType{"pre"} TagTokenType{Tag:"tag", Args:"args"} TextTokenType{"mid"} ObjTokenType{"object"} TextTokenType{"post"}]`, fmt.Sprint(tokens))
 
@@ -160,3 +194,64 @@ func TestScan_delims(t *testing.T) {
 		})
 	}
 }
+
+var scannerMalformedTests = []struct{ in, expected string }{
+	{`{{ x`, `[TextTokenType{"{{ x"}]`},
+	{`{% if x`, `[TextTokenType{"{% if x"}]`},
+	{`{{ x }`, `[TextTokenType{"{{ x }"}]`},
+	{`{{}}`, `[TextTokenType{"{{}}"}]`},
+	{`{%%}`, `[TextTokenType{"{%%}"}]`},
+	{`{% {{ %}`, `[TextTokenType{"{% {{ %}"}]`},
+	{`x }} y %} z`, `[TextTokenType{"x }} y %} z"}]`},
+	{`{{ x }}}`, `[ObjTokenType{"x"} TextTokenType{"}"}]`},
+}
+
+func TestScan_malformed(t *testing.T) {
+	for i, test := range scannerMalformedTests {
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			tokens := Scan(test.in, SourceLoc{}, nil)
+			require.Equalf(t, test.expected, fmt.Sprint(tokens), test.in)
+		})
+	}
+}
+
+var scannerDelimTrimTests = []struct {
+	delims      []string
+	in          string
+	left, right bool
+}{
+	{[]string{"<", ">", "[", "]"}, `<a>`, false, false},
+	{[]string{"<", ">", "[", "]"}, `<-a>`, true, false},
+	{[]string{"<", ">", "[", "]"}, `<a->`, false, true},
+	{[]string{"<", ">", "[", "]"}, `[t-]`, false, true},
+	{[]string{"<", ">", "[", "]"}, `[-t]`, true, false},
+	{[]string{"<<<", ">>>", "<%%", "%%>"}, `<<<-a->>>`, true, true},
+	{[]string{"<<<", ">>>", "<%%", "%%>"}, `<<<a->>>`, false, true},
+	{[]string{"<<<", ">>>", "<%%", "%%>"}, `<%%-t%%>`, true, false},
+}
+
+func TestScan_delims_trim(t *testing.T) {
+	for i, test := range scannerDelimTrimTests {
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			tokens := Scan(test.in, SourceLoc{}, test.delims)
+			require.Lenf(t, tokens, 1, test.in)
+			require.Equalf(t, test.left, tokens[0].TrimLeft, test.in)
+			require.Equalf(t, test.right, tokens[0].TrimRight, test.in)
+		})
+	}
+}
+
+func TestScan_source_round_trip(t *testing.T) {
+	sources := []string{
+		"pre {{x}}\t{{- y -}}  \n{%if a%}{%   else\n%}{% endif %} {% # note %}{# c #}\r\n post",
+		"{%- liquid\n  assign x = 1\n\n  # a comment\n  echo x \n-%}{% liquid %}\n",
+		"{{ x }\n{% unterminated",
+	}
+	for _, src := range sources {
+		var buf strings.Builder
+		for _, tok := range Scan(src, SourceLoc{}, nil) {
+			buf.WriteString(tok.Source)
+		}
+		require.Equal(t, src, buf.String())
+	}
+}
@@ -24,6 +24,9 @@ const (
 	TagTokenType
 	// ObjTokenType is the type of an object Chunk "{{…}}"
 	ObjTokenType
+	// CommentTokenType is the type of an inline comment Chunk "{#…#}" (with the
+	// default tag delimiters) or "{% #… %}"
+	CommentTokenType
 )
 
 // SourceLoc contains a Token's source location. Pathname is in the local file
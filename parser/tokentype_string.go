@@ -4,9 +4,9 @@ package parser
 
 import "fmt"
 
-const _TokenType_name = "TextTokenTypeTagTokenTypeObjTokenType"
+const _TokenType_name = "TextTokenTypeTagTokenTypeObjTokenTypeCommentTokenType"
 
-var _TokenType_index = [...]uint8{0, 13, 25, 37}
+var _TokenType_index = [...]uint8{0, 13, 25, 37, 53}
 
 func (i TokenType) String() string {
 	if i < 0 || i >= TokenType(len(_TokenType_index)-1) {
@@ -15,6 +15,7 @@ type blockSyntax struct {
 	name                  string
 	isClauseTag, isEndTag bool
 	startName             string          // for an end tag, the name of the correspondign start tag
+	opaque                bool            // the body is text, as for raw
 	parents               map[string]bool // if non-nil, must be an immediate clause of one of these
 	parser                BlockCompiler
 }
@@ -34,6 +35,7 @@ func (s *blockSyntax) IsBlock() bool        { return true }
 func (s *blockSyntax) IsBlockEnd() bool     { return s.isEndTag }
 func (s *blockSyntax) IsBlockStart() bool   { return !s.isClauseTag && !s.isEndTag }
 func (s *blockSyntax) IsClause() bool       { return s.isClauseTag }
+func (s *blockSyntax) IsOpaque() bool       { return s.opaque }
 func (s *blockSyntax) RequiresParent() bool { return s.isClauseTag || s.isEndTag }
 
 func (s *blockSyntax) ParentTags() (parents []string) {
@@ -76,6 +78,17 @@ func (g grammar) AddBlock(name string) blockDefBuilder { // nolint: golint
 	return blockDefBuilder{g, ct}
 }
 
+// AddOpaqueBlock defines a block whose body is text, as for raw. The parser
+// doesn't look for tags and objects in the body, so it can contain Liquid
+// syntax, or unmatched delimiters, up to the first matching end tag. The
+// block's compiler or renderer sees the body as a single text node; its
+// InnerString is the body, verbatim.
+func (g grammar) AddOpaqueBlock(name string) blockDefBuilder { // nolint: golint
+	b := g.AddBlock(name)
+	b.tag.opaque = true
+	return b
+}
+
 // Clause tells the parser that the named tag can appear immediately between this tag and its end tag,
 // so long as it is not nested within any other control tag.
 func (b blockDefBuilder) Clause(name string) blockDefBuilder {
@@ -21,4 +21,12 @@ func TestBlockSyntax(t *testing.T) {
 	require.True(t, elseBlock.CanHaveParent(ifBlock))
 	require.False(t, elseBlock.CanHaveParent(unlessBlock))
 	require.Equal(t, []string{"case", "if"}, elseBlock.ParentTags())
+	require.False(t, ifBlock.IsOpaque())
+
+	cfg.AddOpaqueBlock("verbatim")
+	verbatimBlock, _ := g.findBlockDef("verbatim")
+	endBlock, _ := g.findBlockDef("endverbatim")
+	require.True(t, verbatimBlock.IsOpaque())
+	require.True(t, endBlock.CanHaveParent(verbatimBlock))
+	require.Panics(t, func() { cfg.AddOpaqueBlock("if") })
 }
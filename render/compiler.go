@@ -2,6 +2,7 @@ package render
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/osteele/liquid/parser"
 )
@@ -61,9 +62,12 @@ func (c Config) compileNode(n parser.ASTNode) (Node, parser.Error) {
 			}
 			return &TagNode{n.Token, f}, nil
 		}
+		if f := c.unknownTagRenderer(n.Token); f != nil {
+			return &TagNode{n.Token, f}, nil
+		}
 		return nil, parser.Errorf(n, "undefined tag %q", n.Name)
 	case *parser.ASTText:
-		return &TextNode{n.Token}, nil
+		return &TextNode{n.Token, []byte(n.Source)}, nil
 	case *parser.ASTObject:
 		return &ObjectNode{n.Token, n.Expr}, nil
 	default:
@@ -71,6 +75,30 @@ func (c Config) compileNode(n parser.ASTNode) (Node, parser.Error) {
 	}
 }
 
+// unknownTagRenderer returns the renderer for a tag that isn't defined, or
+// nil if the configuration doesn't provide one.
+func (c Config) unknownTagRenderer(tok parser.Token) func(io.Writer, Context) error {
+	switch {
+	case c.UnknownTagHandler != nil:
+		handler := c.UnknownTagHandler
+		return func(w io.Writer, _ Context) error {
+			s, err := handler(tok.Name, tok.Args)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(w, s)
+			return err
+		}
+	case c.PassThroughUnknownTags:
+		return func(w io.Writer, _ Context) error {
+			_, err := io.WriteString(w, tok.Source)
+			return err
+		}
+	default:
+		return nil
+	}
+}
+
 func (c Config) compileBlocks(blocks []*parser.ASTBlock) ([]*BlockNode, parser.Error) {
 	out := make([]*BlockNode, 0, len(blocks))
 	for _, child := range blocks {
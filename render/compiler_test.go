@@ -1,6 +1,7 @@
 package render
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"testing"
@@ -39,3 +40,43 @@ func TestCompile_errors(t *testing.T) {
 		})
 	}
 }
+
+func TestCompile_unknownTags(t *testing.T) {
+	render := func(cfg Config, src string) (string, error) {
+		root, err := cfg.Compile(src, parser.SourceLoc{})
+		if err != nil {
+			return "", err
+		}
+		buf := new(bytes.Buffer)
+		err = Render(root, buf, map[string]interface{}{}, cfg)
+		return buf.String(), err
+	}
+
+	cfg := NewConfig()
+	addCompilerTestTags(cfg)
+	cfg.PassThroughUnknownTags = true
+	out, err := render(cfg, `a{% section 'header' %}b{% block %}{%unknown%}{% endblock %}`)
+	require.NoError(t, err)
+	require.Equal(t, `a{% section 'header' %}b`, out)
+	out, err = render(cfg, `a{% section x %}{{ 1 }}{% endsection %}`)
+	require.NoError(t, err)
+	require.Equal(t, `a{% section x %}1{% endsection %}`, out)
+
+	cfg.UnknownTagHandler = func(name, params string) (string, error) {
+		if name == "fail" {
+			return "", fmt.Errorf("cannot render %s", name)
+		}
+		return fmt.Sprintf("<%s:%s>", name, params), nil
+	}
+	out, err = render(cfg, `a{% section 'header' %}b`)
+	require.NoError(t, err)
+	require.Equal(t, `a<section:'header'>b`, out)
+	_, err = render(cfg, `{% fail %}`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot render fail")
+
+	// defined tags are unaffected
+	out, err = render(cfg, `{% block %}{% endblock %}`)
+	require.NoError(t, err)
+	require.Equal(t, "", out)
+}
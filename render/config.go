@@ -1,15 +1,86 @@
 package render
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
 	"github.com/osteele/liquid/parser"
 )
 
-// Config holds configuration information for parsing and rendering.
+// Config holds configuration information for parsing and rendering. The
+// settings of the standard filters, such as Markdown and Rand, are in the
+// embedded expressions.Config.
 type Config struct {
 	parser.Config
 	grammar
 	Cache           map[string][]byte
 	StrictVariables bool
+	// MaxIterations limits the total number of loop iterations in a render,
+	// across all loops. Zero means no limit. (MaxOutputBytes is in the
+	// embedded expressions.Config, since filters use it too.)
+	MaxIterations int
+	// Globals are variables that are visible to every render. A variable that
+	// is passed to the render, or assigned by the template, shadows a global
+	// with the same name; the map itself isn't modified.
+	Globals map[string]interface{}
+	// TemplateRoot, if it is not empty, is the directory that contains the
+	// files that tags such as include can read. A filename that resolves to a
+	// location outside it, for example by way of ".." or a symbolic link, is an
+	// error, and so is an absolute filename.
+	TemplateRoot string
+	// UnknownTagHandler renders tags that haven't been defined, instead of
+	// Compile reporting an error. It is called with the tag's name and
+	// arguments, and its result is written in place of the tag.
+	UnknownTagHandler func(name, params string) (string, error)
+	// PassThroughUnknownTags writes the source of tags that haven't been
+	// defined, such as "{% widget 'header' %}", to the output unchanged. It
+	// has no effect if UnknownTagHandler is set.
+	PassThroughUnknownTags bool
+	// Sections configures the {% section %} and {% sections %} tags.
+	Sections Sections
+	// FormTypes adds to, or replaces, the form types that {% form "type" %}
+	// supports, such as "contact" and "product".
+	FormTypes map[string]FormType
+}
+
+// A FormType describes the form that {% form "type" %} writes.
+type FormType struct {
+	// Action is the URL of the form's action attribute.
+	Action string
+	// ID is the form's id attribute. If the tag names an object that has an
+	// id property, such as a product, it's appended: "product_form_123".
+	ID string
+	// Class is the form's class attribute.
+	Class string
+	// Enctype, if it isn't empty, is the form's enctype attribute.
+	Enctype string
+	// Fields are hidden inputs that are written after the form_type and utf8
+	// inputs, in order of name.
+	Fields map[string]string
+}
+
+// Sections configures the {% section %} and {% sections %} tags, which render
+// Shopify theme sections.
+type Sections struct {
+	// Dir is the directory from which {% section "name" %} reads name.liquid.
+	// If it is empty, sections are read from the "sections" directory of the
+	// TemplateRoot, or of the current directory if there is no root.
+	Dir string
+	// Settings holds each section's settings, by section name. A section is
+	// rendered with its settings bound to section.settings.
+	Settings map[string]map[string]interface{}
+	// Groups lists the sections that {% sections "name" %} renders, in order.
+	Groups map[string][]string
+}
+
+// SectionFilename returns the name of the file that defines the named section.
+func (c Config) SectionFilename(name string) string {
+	dir := c.Sections.Dir
+	if dir == "" {
+		dir = filepath.Join(c.TemplateRoot, "sections")
+	}
+	return filepath.Join(dir, name+".liquid")
 }
 
 type grammar struct {
@@ -25,3 +96,108 @@ func NewConfig() Config {
 	}
 	return Config{Config: parser.NewConfig(g), grammar: g, Cache: map[string][]byte{}}
 }
+
+// checkTemplatePath returns an error if c.TemplateRoot is set and filename is
+// outside it.
+func (c Config) checkTemplatePath(filename string) error {
+	if c.TemplateRoot == "" {
+		return nil
+	}
+	root, err := resolvePath(c.TemplateRoot)
+	if err != nil {
+		return err
+	}
+	path, err := resolvePath(filename)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s is outside the template root %s", filename, c.TemplateRoot)
+	}
+	return nil
+}
+
+// resolvePath returns the absolute path of filename, with symbolic links
+// resolved. If the file doesn't exist, the path is only made absolute; reading
+// it will fail anyway.
+func resolvePath(filename string) (string, error) {
+	if resolved, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = resolved
+	}
+	return filepath.Abs(filename)
+}
+
+// Clone returns a deep copy of the configuration. Tags, blocks, and filters
+// that are added to the copy are not visible to the original, so that a shared
+// base configuration can be customized per use.
+func (c Config) Clone() Config {
+	g := c.grammar.clone()
+	clone := c
+	clone.grammar = g
+	clone.Config.Config = c.Config.Config.Clone()
+	clone.Config.Grammar = g
+	clone.Config.Delims = append([]string(nil), c.Delims...)
+	clone.Cache = make(map[string][]byte, len(c.Cache))
+	for k, v := range c.Cache {
+		clone.Cache[k] = v
+	}
+	if c.Globals != nil {
+		clone.Globals = make(map[string]interface{}, len(c.Globals))
+		for k, v := range c.Globals {
+			clone.Globals[k] = v
+		}
+	}
+	if c.Sections.Settings != nil {
+		clone.Sections.Settings = make(map[string]map[string]interface{}, len(c.Sections.Settings))
+		for name, settings := range c.Sections.Settings {
+			if settings != nil {
+				copied := make(map[string]interface{}, len(settings))
+				for k, v := range settings {
+					copied[k] = v
+				}
+				settings = copied
+			}
+			clone.Sections.Settings[name] = settings
+		}
+	}
+	if c.Sections.Groups != nil {
+		clone.Sections.Groups = make(map[string][]string, len(c.Sections.Groups))
+		for name, group := range c.Sections.Groups {
+			clone.Sections.Groups[name] = append([]string(nil), group...)
+		}
+	}
+	if c.FormTypes != nil {
+		clone.FormTypes = make(map[string]FormType, len(c.FormTypes))
+		for k, ft := range c.FormTypes {
+			if ft.Fields != nil {
+				fields := make(map[string]string, len(ft.Fields))
+				for name, value := range ft.Fields {
+					fields[name] = value
+				}
+				ft.Fields = fields
+			}
+			clone.FormTypes[k] = ft
+		}
+	}
+	return clone
+}
+
+func (g grammar) clone() grammar {
+	tags := make(map[string]TagCompiler, len(g.tags))
+	for k, v := range g.tags {
+		tags[k] = v
+	}
+	blockDefs := make(map[string]*blockSyntax, len(g.blockDefs))
+	for k, v := range g.blockDefs {
+		def := *v
+		if v.parents != nil {
+			def.parents = make(map[string]bool, len(v.parents))
+			for p := range v.parents {
+				def.parents[p] = true
+			}
+		}
+		blockDefs[k] = &def
+	}
+	return grammar{tags, blockDefs}
+}
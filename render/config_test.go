@@ -0,0 +1,202 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osteele/liquid/filters"
+	"github.com/osteele/liquid/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Clone(t *testing.T) {
+	base := NewConfig()
+	base.AddFilter("f", func(s string) string { return "base" })
+	base.AddBlock("block").Clause("clause")
+	base.Cache["file.html"] = []byte("base")
+	base.Globals = map[string]interface{}{"v": "base"}
+	base.FormTypes = map[string]FormType{"f": {Action: "base", Fields: map[string]string{"v": "base"}}}
+	base.Sections = Sections{
+		Settings: map[string]map[string]interface{}{"s": {"v": "base"}},
+		Groups:   map[string][]string{"g": {"base"}},
+	}
+
+	clone := base.Clone()
+	clone.AddFilter("f", func(s string) string { return "clone" })
+	clone.AddFilter("g", func(s string) string { return s })
+	clone.AddTag("tag", func(string) (func(io.Writer, Context) error, error) {
+		return func(io.Writer, Context) error { return nil }, nil
+	})
+	clone.AddBlock("other")
+	clone.AddBlock("block2").Clause("clause")
+	clone.Cache["file.html"] = []byte("clone")
+	clone.Delims = []string{"<<", ">>", "<%", "%>"}
+	clone.Globals["v"] = "clone"
+	clone.FormTypes["f"].Fields["v"] = "clone"
+	clone.FormTypes["g"] = FormType{Action: "clone"}
+	clone.Sections.Settings["s"]["v"] = "clone"
+	clone.Sections.Settings["t"] = map[string]interface{}{}
+	clone.Sections.Groups["g"][0] = "clone"
+	clone.Sections.Groups["h"] = nil
+
+	render := func(c Config, src string) (string, error) {
+		root, err := c.Compile(src, parser.SourceLoc{})
+		if err != nil {
+			return "", err
+		}
+		buf := new(bytes.Buffer)
+		if err := Render(root, buf, map[string]interface{}{}, c); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	// the clone sees its own definitions
+	out, err := render(clone, `<< "x" | f >><< "y" | g >><% tag %>`)
+	require.NoError(t, err)
+	require.Equal(t, "cloney", out)
+	_, err = clone.Compile(`<% other %><% endother %><% block2 %><% clause %><% endblock2 %>`, parser.SourceLoc{})
+	require.NoError(t, err)
+
+	// the base is unchanged
+	out, err = render(base, `{{ "x" | f }}`)
+	require.NoError(t, err)
+	require.Equal(t, "base", out)
+	_, err = render(base, `{{ "y" | g }}`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "undefined filter")
+	_, err = render(base, `{% tag %}`)
+	require.Error(t, err)
+	_, err = base.Compile(`{% other %}{% endother %}`, parser.SourceLoc{})
+	require.Error(t, err)
+	_, err = base.Compile(`{% block2 %}{% clause %}{% endblock2 %}`, parser.SourceLoc{})
+	require.Error(t, err)
+	require.Nil(t, base.Delims)
+	require.Equal(t, "base", string(base.Cache["file.html"]))
+	require.Equal(t, "base", base.Globals["v"])
+	require.Equal(t, map[string]FormType{"f": {Action: "base", Fields: map[string]string{"v": "base"}}}, base.FormTypes)
+	require.Equal(t, map[string]map[string]interface{}{"s": {"v": "base"}}, base.Sections.Settings)
+	require.Equal(t, map[string][]string{"g": {"base"}}, base.Sections.Groups)
+}
+
+func TestConfig_Clone_markdown(t *testing.T) {
+	base := NewConfig()
+	filters.AddStandardFilters(&base)
+	clone := base.Clone()
+	clone.Markdown = strings.ToUpper
+
+	render := func(c Config) string {
+		root, err := c.Compile(`{{ "abc" | markdownify }}`, parser.SourceLoc{})
+		require.NoError(t, err)
+		buf := new(bytes.Buffer)
+		require.NoError(t, Render(root, buf, map[string]interface{}{}, c))
+		return buf.String()
+	}
+	require.Equal(t, "ABC", render(clone))
+	require.Equal(t, "abc", render(base))
+}
+
+func TestConfig_AddTag_override(t *testing.T) {
+	tag := func(s string) TagCompiler {
+		return func(string) (func(io.Writer, Context) error, error) {
+			return func(w io.Writer, _ Context) error {
+				_, err := io.WriteString(w, s)
+				return err
+			}, nil
+		}
+	}
+	cfg := NewConfig()
+	cfg.AddTag("tag", tag("first"))
+	cfg.AddBlock("block").Compiler(func(BlockNode) (func(io.Writer, Context) error, error) {
+		return tag("block")("")
+	})
+	other := cfg.Clone()
+
+	cfg.AddTag("tag", tag("second"))
+	cfg.AddTag("block", tag("tag"))
+	root, err := cfg.Compile(`{% tag %} {% block %}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	require.NoError(t, Render(root, buf, map[string]interface{}{}, cfg))
+	require.Equal(t, "second tag", buf.String())
+	_, err = cfg.Compile(`{% block %}{% endblock %}`, parser.SourceLoc{})
+	require.Error(t, err)
+
+	// the override is local to the Config
+	root, err = other.Compile(`{% tag %}{% block %}{% endblock %}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	buf = new(bytes.Buffer)
+	require.NoError(t, Render(root, buf, map[string]interface{}{}, other))
+	require.Equal(t, "firstblock", buf.String())
+}
+
+func TestConfig_Clone_rand(t *testing.T) {
+	base := NewConfig()
+	filters.AddStandardFilters(&base)
+	clone := base.Clone()
+	root, err := clone.Compile(`{{ a | shuffle | join }}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	bindings := map[string]interface{}{"a": []int{1, 2, 3, 4, 5}}
+
+	render := func() string {
+		clone.Rand = rand.New(rand.NewSource(1)) // nolint: gosec
+		buf := new(bytes.Buffer)
+		require.NoError(t, Render(root, buf, bindings, clone))
+		return buf.String()
+	}
+	require.Equal(t, render(), render())
+}
+
+func TestRender_concurrentRand(t *testing.T) {
+	cfg := NewConfig()
+	filters.AddStandardFilters(&cfg)
+	cfg.Rand = rand.New(rand.NewSource(1)) // nolint: gosec
+	root, err := cfg.Compile(`{{ a | shuffle | join }} {{ a | sample }}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	bindings := map[string]interface{}{"a": []int{1, 2, 3, 4, 5}}
+
+	// run with -race to check that the renders don't share Rand unguarded
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := Render(root, ioutil.Discard, bindings, cfg); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
+
+func TestConfig_randLock(t *testing.T) {
+	base := NewConfig()
+	base.Rand = rand.New(rand.NewSource(1)) // nolint: gosec
+	other := NewConfig()
+	other.Rand = rand.New(rand.NewSource(2)) // nolint: gosec
+
+	// configurations that aren't clones of each other don't share a lock
+	done := make(chan bool)
+	go base.WithRandomSource(func(*rand.Rand) {
+		other.WithRandomSource(func(*rand.Rand) { done <- true })
+	})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the random sources of unrelated configurations share a lock")
+	}
+}
@@ -16,6 +16,15 @@ import (
 type Context interface {
 	// Bindings returns the current lexical environment.
 	Bindings() map[string]interface{}
+	// Config returns the configuration of the current render. It's used in the
+	// implementation of tags, such as {% section %}, whose behavior is
+	// configured there.
+	Config() Config
+	// CountIteration is used in the implementation of the iteration tags. It
+	// returns an error if the render has exceeded Config.MaxIterations, or if
+	// the context passed to RenderCtx is done.
+	// It's not guaranteed stable.
+	CountIteration() error
 	// Get retrieves the value of a variable from the current lexical environment.
 	Get(name string) interface{}
 	// Errorf creates a SourceError, that includes the source location.
@@ -111,9 +120,20 @@ func (c rendererContext) Bindings() map[string]interface{} {
 	return c.ctx.bindings
 }
 
-// Get gets a variable value within an evaluation context.
+// Config returns the render's configuration.
+func (c rendererContext) Config() Config {
+	return c.ctx.config
+}
+
+// CountIteration records a loop iteration.
+func (c rendererContext) CountIteration() error {
+	return c.ctx.countIteration()
+}
+
+// Get gets a variable value within an evaluation context. Lazy bindings are
+// resolved, as they are in expressions.
 func (c rendererContext) Get(name string) interface{} {
-	return c.ctx.bindings[name]
+	return expressions.ResolveLazy(c.ctx.bindings[name])
 }
 
 func (c rendererContext) ExpandTagArg() (string, error) {
@@ -147,6 +167,9 @@ func (c rendererContext) RenderChildren(w io.Writer) Error {
 }
 
 func (c rendererContext) RenderFile(filename string, b map[string]interface{}) (string, error) {
+	if err := c.ctx.config.checkTemplatePath(filename); err != nil {
+		return "", err
+	}
 	source, err := ioutil.ReadFile(filename)
 	if err != nil && os.IsNotExist(err) {
 		// Is it cached?
@@ -158,7 +181,8 @@ func (c rendererContext) RenderFile(filename string, b map[string]interface{}) (
 	} else if err != nil {
 		return "", err
 	}
-	root, err := c.ctx.config.Compile(string(source), c.node.SourceLoc)
+	// Relative filenames in the file are resolved against its own directory.
+	root, err := c.ctx.config.Compile(string(source), parser.SourceLoc{Pathname: filename, LineNo: 1})
 	if err != nil {
 		return "", err
 	}
@@ -170,7 +194,8 @@ func (c rendererContext) RenderFile(filename string, b map[string]interface{}) (
 		bindings[k] = v
 	}
 	buf := new(bytes.Buffer)
-	if err := Render(root, buf, bindings, c.ctx.config); err != nil {
+	// The included file shares this render's limits.
+	if err := (nodeContext{bindings, c.ctx.config, c.ctx.state}).render(root, buf); err != nil {
 		return "", err
 	}
 	return buf.String(), nil
@@ -191,13 +216,18 @@ func (c rendererContext) Set(name string, value interface{}) {
 }
 
 func (c rendererContext) SourceFile() string {
+	return c.sourceLoc().Pathname
+}
+
+// sourceLoc returns the source location of the current tag or block.
+func (c rendererContext) sourceLoc() parser.SourceLoc {
 	switch {
 	case c.node != nil:
-		return c.node.SourceLoc.Pathname
+		return c.node.SourceLoc
 	case c.cn != nil:
-		return c.cn.SourceLoc.Pathname
+		return c.cn.SourceLoc
 	default:
-		return ""
+		return parser.SourceLoc{}
 	}
 }
 
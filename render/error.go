@@ -1,6 +1,10 @@
 package render
 
 import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+
 	"github.com/osteele/liquid/parser"
 )
 
@@ -19,3 +23,97 @@ func renderErrorf(loc parser.Locatable, format string, a ...interface{}) Error {
 func wrapRenderError(err error, loc parser.Locatable) Error {
 	return parser.WrapError(err, loc)
 }
+
+// An UndefinedVariableError is the cause of an Error that is reported, when
+// StrictVariables is set, for an object whose value is undefined.
+type UndefinedVariableError struct {
+	Name string // the object expression, such as "page.title"
+}
+
+func (e *UndefinedVariableError) Error() string { return "undefined variable" }
+
+// A LimitError is the cause of an Error when rendering exceeds one of the
+// limits in Config.
+type LimitError struct {
+	Limit string // the name of the Config field, such as "MaxIterations"
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	switch e.Limit {
+	case "MaxIterations":
+		return fmt.Sprintf("exceeded the limit of %d loop iterations", e.Max)
+	case "MaxOutputBytes":
+		return fmt.Sprintf("exceeded the limit of %d bytes of output", e.Max)
+	default:
+		return fmt.Sprintf("exceeded %s (%d)", e.Limit, e.Max)
+	}
+}
+
+// A PanicError is the cause of an Error that was recovered from a panic during
+// rendering, for example in a filter, a tag, or a method of a value.
+type PanicError struct {
+	Value interface{} // the value that was passed to panic
+	Stack []byte      // the stack trace at the point of recovery
+}
+
+func (e *PanicError) Error() string {
+	if site := panicSite(e.Stack); site != "" {
+		return fmt.Sprintf("panic in %s: %v", site, e.Value)
+	}
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// recoverPanic converts a panic into an Error at loc. Use it as
+// defer recoverPanic(&err, loc).
+func recoverPanic(errp *Error, loc parser.Locatable) {
+	if r := recover(); r != nil {
+		// The expression evaluator re-panics errors with a wrapper that
+		// records their stack trace; ours includes this too.
+		if e, ok := r.(interface{ Cause() error }); ok && e.Cause() != nil {
+			r = e.Cause()
+		}
+		// A write that exceeds the output limit can panic, for example
+		// from trimWriter.TrimLeft; this isn't an implementation error.
+		if e, ok := r.(*LimitError); ok {
+			*errp = wrapRenderError(e, loc)
+			return
+		}
+		*errp = wrapRenderError(&PanicError{r, debug.Stack()}, loc)
+	}
+}
+
+// panicSite returns the function and file position of the original panic in a
+// stack trace in the format of debug.Stack, or "" if it can't be found.
+func panicSite(stack []byte) string {
+	lines := strings.Split(string(stack), "\n")
+	// Deferred functions that re-panic run above the original panic, so the
+	// last panic in the trace is the original.
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "panic(") {
+			start = i + 2 // skip panic's own file:line
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+	// Each frame is a function line followed by an indented file:line line.
+	for i := start; i+1 < len(lines); i += 2 {
+		fn, pos := lines[i], strings.TrimSpace(lines[i+1])
+		if strings.HasPrefix(fn, "runtime.") {
+			continue
+		}
+		if n := strings.LastIndex(fn, "("); n > 0 {
+			fn = fn[:n]
+		}
+		if n := strings.LastIndex(pos, " +0x"); n > 0 {
+			pos = pos[:n]
+		}
+		if n := strings.LastIndex(pos, "/"); n >= 0 {
+			pos = pos[n+1:]
+		}
+		return fmt.Sprintf("%s (%s)", fn, pos)
+	}
+	return ""
+}
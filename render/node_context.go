@@ -1,6 +1,9 @@
 package render
 
 import (
+	"context"
+	"sync"
+
 	"github.com/osteele/liquid/expressions"
 )
 
@@ -11,6 +14,14 @@ import (
 type nodeContext struct {
 	bindings map[string]interface{}
 	config   Config
+	state    *renderState
+}
+
+// renderState is shared by the contexts of a single render, including those of
+// included files.
+type renderState struct {
+	context    context.Context
+	iterations int
 }
 
 // newNodeContext creates a new evaluation context.
@@ -18,10 +29,49 @@ func newNodeContext(scope map[string]interface{}, c Config) nodeContext {
 	// The assign tag modifies the scope, so make a copy first.
 	// TODO this isn't really the right place for this.
 	vars := map[string]interface{}{}
+	for k, v := range c.Globals {
+		vars[k] = memoizeLazy(v)
+	}
 	for k, v := range scope {
-		vars[k] = v
+		vars[k] = memoizeLazy(v)
+	}
+	return nodeContext{vars, c, &renderState{context: context.Background()}}
+}
+
+// memoizeLazy wraps a lazy binding so that it's called at most once per
+// render, although each evaluation makes a new expression context. Other
+// bindings are returned unchanged.
+func memoizeLazy(value interface{}) interface{} {
+	if !expressions.IsLazy(value) {
+		return value
+	}
+	var (
+		once   sync.Once
+		result interface{}
+	)
+	return func() interface{} {
+		once.Do(func() { result = expressions.ResolveLazy(value) })
+		return result
+	}
+}
+
+// checkContext returns the context's error, if it has been canceled or its
+// deadline has passed.
+func (c nodeContext) checkContext() error {
+	return c.state.context.Err()
+}
+
+// countIteration records a loop iteration. It returns an error if this
+// exceeds the configured maximum, or if the render's context is done.
+func (c nodeContext) countIteration() error {
+	if err := c.checkContext(); err != nil {
+		return err
+	}
+	c.state.iterations++
+	if max := c.config.MaxIterations; max > 0 && c.state.iterations > max {
+		return &LimitError{"MaxIterations", max}
 	}
-	return nodeContext{vars, c}
+	return nil
 }
 
 // Evaluate evaluates an expression within the template context.
@@ -37,6 +37,9 @@ type TagNode struct {
 // TextNode is a text chunk, that is rendered verbatim.
 type TextNode struct {
 	parser.Token
+	// text is Source as bytes. It's converted once, when the template is
+	// compiled, and written directly instead of being copied on each render.
+	text []byte
 }
 
 // ObjectNode is an {{ object }} object.
@@ -2,7 +2,7 @@
 package render
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"io"
 	"reflect"
@@ -12,21 +12,83 @@ import (
 )
 
 // Render renders the render tree.
+//
+// A panic during rendering, for example in a filter or tag implementation, is
+// returned as an Error whose Cause is a *PanicError.
 func Render(node Node, w io.Writer, vars map[string]interface{}, c Config) Error {
-	tw := trimWriter{w: w}
-	if err := node.render(&tw, newNodeContext(vars, c)); err != nil {
+	return RenderCtx(context.Background(), node, w, vars, c)
+}
+
+// RenderCtx is like Render, but stops with ctx.Err() if ctx is canceled or
+// its deadline passes. The context is checked before each node of a sequence
+// is rendered, and before each loop iteration.
+func RenderCtx(ctx context.Context, node Node, w io.Writer, vars map[string]interface{}, c Config) Error {
+	nc := newNodeContext(vars, c)
+	nc.state.context = ctx
+	return nc.render(node, w)
+}
+
+// render renders a node within the context.
+func (c nodeContext) render(node Node, w io.Writer) (err Error) {
+	defer recoverPanic(&err, invalidLoc)
+	tw := trimWriter{w: c.limitOutput(w)}
+	if err := node.render(&tw, c); err != nil {
 		return err
 	}
 	if err := tw.Flush(); err != nil {
-		panic(err)
+		return wrapRenderError(err, invalidLoc)
 	}
 	return nil
 }
 
+// A limitWriter fails once more than max bytes have been written to it.
+type limitWriter struct {
+	w              io.Writer
+	max, remaining int
+}
+
+func (lw *limitWriter) Write(b []byte) (int, error) {
+	if len(b) > lw.remaining {
+		return 0, &LimitError{"MaxOutputBytes", lw.max}
+	}
+	lw.remaining -= len(b)
+	return lw.w.Write(b)
+}
+
+// limitOutput returns a writer that limits the bytes written to w to
+// MaxOutputBytes, unless w is already limited. The output of a render, and
+// each buffer that a tag such as capture or include renders into, has its own
+// limit, so that a byte that is buffered and then written counts once
+// against each.
+func (c nodeContext) limitOutput(w io.Writer) io.Writer {
+	max := c.config.MaxOutputBytes
+	if max <= 0 || isLimited(w) {
+		return w
+	}
+	return &limitWriter{w, max, max}
+}
+
+// isLimited returns true if w, or a writer that it wraps, is a limitWriter.
+func isLimited(w io.Writer) bool {
+	for {
+		switch tw := w.(type) {
+		case *limitWriter:
+			return true
+		case *trimWriter:
+			w = tw.w
+		default:
+			return false
+		}
+	}
+}
+
 // RenderASTSequence renders a sequence of nodes.
 func (c nodeContext) RenderSequence(w io.Writer, seq []Node) Error {
-	tw := trimWriter{w: w}
+	tw := trimWriter{w: c.limitOutput(w)}
 	for _, n := range seq {
+		if err := c.checkContext(); err != nil {
+			return wrapRenderError(err, n)
+		}
 		if err := n.render(&tw, c); err != nil {
 			return err
 		}
@@ -37,7 +99,8 @@ func (c nodeContext) RenderSequence(w io.Writer, seq []Node) Error {
 	return nil
 }
 
-func (n *BlockNode) render(w *trimWriter, ctx nodeContext) Error {
+func (n *BlockNode) render(w *trimWriter, ctx nodeContext) (err Error) {
+	defer recoverPanic(&err, n)
 	cd, ok := ctx.config.findBlockDef(n.Name)
 	if !ok || cd.parser == nil {
 		// this should have been detected during compilation; it's an implementation error if it happens here
@@ -48,9 +111,9 @@ func (n *BlockNode) render(w *trimWriter, ctx nodeContext) Error {
 		panic(fmt.Errorf("unset renderer for %v", n))
 	}
 	w.TrimLeft(n.TrimLeft)
-	err := renderer(w, rendererContext{ctx, nil, n})
-	w.TrimRight(n.TrimRight)
-	return wrapRenderError(err, n)
+	// The parser has already applied the block's trim markers to the text
+	// within and after it.
+	return wrapRenderError(renderer(w, rendererContext{ctx, nil, n}), n)
 }
 
 func (n *RawNode) render(w *trimWriter, ctx nodeContext) Error {
@@ -63,14 +126,15 @@ func (n *RawNode) render(w *trimWriter, ctx nodeContext) Error {
 	return nil
 }
 
-func (n *ObjectNode) render(w *trimWriter, ctx nodeContext) Error {
+func (n *ObjectNode) render(w *trimWriter, ctx nodeContext) (err Error) {
+	defer recoverPanic(&err, n)
 	w.TrimLeft(n.TrimLeft)
-	value, err := ctx.Evaluate(n.expr)
-	if err != nil {
-		return wrapRenderError(err, n)
+	value, evalErr := ctx.Evaluate(n.expr)
+	if evalErr != nil {
+		return wrapRenderError(evalErr, n)
 	}
 	if value == nil && ctx.config.StrictVariables {
-		return wrapRenderError(errors.New("undefined variable"), n)
+		return wrapRenderError(&UndefinedVariableError{n.Args}, n)
 	}
 	if err := wrapRenderError(writeObject(w, value), n); err != nil {
 		return err
@@ -81,6 +145,9 @@ func (n *ObjectNode) render(w *trimWriter, ctx nodeContext) Error {
 
 func (n *SeqNode) render(w *trimWriter, ctx nodeContext) Error {
 	for _, c := range n.Children {
+		if err := ctx.checkContext(); err != nil {
+			return wrapRenderError(err, c)
+		}
 		if err := c.render(w, ctx); err != nil {
 			return err
 		}
@@ -88,15 +155,21 @@ func (n *SeqNode) render(w *trimWriter, ctx nodeContext) Error {
 	return nil
 }
 
-func (n *TagNode) render(w *trimWriter, ctx nodeContext) Error {
+func (n *TagNode) render(w *trimWriter, ctx nodeContext) (err Error) {
+	defer recoverPanic(&err, n)
 	w.TrimLeft(n.TrimLeft)
-	err := wrapRenderError(n.renderer(w, rendererContext{ctx, n, nil}), n)
+	err = wrapRenderError(n.renderer(w, rendererContext{ctx, n, nil}), n)
 	w.TrimRight(n.TrimRight)
 	return err
 }
 
 func (n *TextNode) render(w *trimWriter, ctx nodeContext) Error {
-	_, err := io.WriteString(w, n.Source)
+	text := n.text
+	if text == nil {
+		// the node wasn't created by the compiler
+		text = []byte(n.Source)
+	}
+	_, err := w.Write(text)
 	return wrapRenderError(err, n)
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,6 +29,11 @@ var renderTests = []struct{ in, out string }{
 	{`{{ page.title }}`, "Introduction"},
 	{`{{ array[1] }}`, "second"},
 
+	// inline comments
+	{`x{# comment #}y`, "xy"},
+	{`x {# {{ int }} {% y %} #} y`, "x  y"},
+	{"{# multi\nline #}{{ int }}", "123"},
+
 	// whitespace control
 	{` {{ 1 }} `, " 1 "},
 	{` {{- 1 }} `, "1 "},
@@ -128,6 +134,100 @@ func TestRenderErrors(t *testing.T) {
 	}
 }
 
+func TestRender_panics(t *testing.T) {
+	cfg := NewConfig()
+	addRenderTestTags(cfg)
+	cfg.AddFilter("panic", func(s string) string { panic("filter panic") })
+	cfg.AddFilter("nil_map", func(s string) string {
+		var m map[string]string
+		m[s] = s
+		return s
+	})
+	cfg.AddTag("panic", func(string) (func(io.Writer, Context) error, error) {
+		return func(io.Writer, Context) error { panic("tag panic") }, nil
+	})
+	cfg.AddBlock("children").Compiler(func(c BlockNode) (func(io.Writer, Context) error, error) {
+		return func(w io.Writer, c Context) error { return c.RenderChildren(w) }, nil
+	})
+	tests := []struct{ in, expected string }{
+		{"{{ 'x' | panic }}", "panic in github.com/osteele/liquid/render.TestRender_panics.func1 (render_test.go:"},
+		{"{{ 'x' | nil_map }}", "assignment to entry in nil map"},
+		{"line 1\n{% panic %}", "tag panic"},
+		{"{% errblock %}{% enderrblock %}{% panic %}", "errblock error"},
+		{"{% children %}{{ 'x' | panic }}{% endchildren %}", "filter panic"},
+	}
+	for _, test := range tests {
+		root, err := cfg.Compile(test.in, parser.SourceLoc{Pathname: "template.html", LineNo: 1})
+		require.NoErrorf(t, err, test.in)
+		err = Render(root, ioutil.Discard, renderTestBindings, cfg)
+		require.Errorf(t, err, test.in)
+		require.Containsf(t, err.Error(), test.expected, test.in)
+	}
+
+	root, err := cfg.Compile("line 1\n{{ 'x' | panic }}", parser.SourceLoc{Pathname: "template.html", LineNo: 1})
+	require.NoError(t, err)
+	rerr := Render(root, ioutil.Discard, renderTestBindings, cfg)
+	require.Error(t, rerr)
+	require.Equal(t, 2, rerr.LineNumber())
+	require.Equal(t, "template.html", rerr.Path())
+	require.IsType(t, &PanicError{}, rerr.Cause())
+	require.Equal(t, "filter panic", rerr.Cause().(*PanicError).Value)
+	require.NotEmpty(t, rerr.Cause().(*PanicError).Stack)
+}
+
+func TestRender_maxOutputBytes(t *testing.T) {
+	cfg := NewConfig()
+	addRenderTestTags(cfg)
+	cfg.MaxOutputBytes = 5
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{`{{ "abc" }}{{ "de" }}`, true},
+		{`{{ "abcdef" }}`, false},
+		{`abc{{ "def" }}`, false},
+		{`abcdefg`, false},
+		{"abcde  {{- 'x' }}", false},
+		{"abcde {%- null %}", true},
+	}
+	for _, test := range tests {
+		root, err := cfg.Compile(test.in, parser.SourceLoc{})
+		require.NoErrorf(t, err, test.in)
+		buf := new(bytes.Buffer)
+		err = Render(root, buf, renderTestBindings, cfg)
+		if test.ok {
+			require.NoErrorf(t, err, test.in)
+			continue
+		}
+		require.Errorf(t, err, test.in)
+		require.Containsf(t, err.Error(), "exceeded the limit of 5 bytes of output", test.in)
+		require.IsTypef(t, &LimitError{}, err.Cause(), test.in)
+	}
+}
+
+func TestRender_globals(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Globals = map[string]interface{}{"site": "global site", "x": "global x"}
+	cfg.AddTag("set", func(string) (func(io.Writer, Context) error, error) {
+		return func(w io.Writer, ctx Context) error {
+			ctx.Set("site", "assigned site")
+			return nil
+		}, nil
+	})
+	root, err := cfg.Compile(`{{ site }}/{{ x }}{% set %}/{{ site }}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	err = Render(root, buf, map[string]interface{}{"x": "render x"}, cfg)
+	require.NoError(t, err)
+	require.Equal(t, "global site/render x/assigned site", buf.String())
+	require.Equal(t, "global site", cfg.Globals["site"])
+
+	buf = new(bytes.Buffer)
+	err = Render(root, buf, nil, cfg)
+	require.NoError(t, err)
+	require.Equal(t, "global site/global x/assigned site", buf.String())
+}
+
 func TestRenderStrictVariables(t *testing.T) {
 	cfg := NewConfig()
 	cfg.StrictVariables = true
@@ -148,6 +248,24 @@ func TestRenderStrictVariables(t *testing.T) {
 	}
 }
 
+func BenchmarkRender_text(b *testing.B) {
+	cfg := NewConfig()
+	addRenderTestTags(cfg)
+	source := strings.Repeat("<p>Lorem ipsum dolor sit amet, consectetur adipiscing elit.</p>\n", 20) +
+		strings.Repeat("<li>{{ int }}</li>\n  {{- float -}}  \n<li>sed do eiusmod tempor</li>\n", 20)
+	root, err := cfg.Compile(source, parser.SourceLoc{})
+	require.NoError(b, err)
+	buf := new(bytes.Buffer)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := Render(root, buf, renderTestBindings, cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func addRenderTestTags(cfg Config) {
 	cfg.AddTag("y", func(string) (func(io.Writer, Context) error, error) {
 		return func(w io.Writer, _ Context) error {
@@ -8,8 +8,16 @@ import (
 // TODO instead of using the bare function definition, use a structure that defines how to parse
 type TagCompiler func(expr string) (func(io.Writer, Context) error, error)
 
-// AddTag creates a tag definition.
+// AddTag creates a tag definition. It replaces any existing tag or block with
+// the same name in this Config, so that a built-in tag such as include can be
+// overridden.
 func (c *Config) AddTag(name string, td TagCompiler) {
+	if bd, ok := c.blockDefs[name]; ok && bd.IsBlockStart() {
+		delete(c.blockDefs, name)
+		if end, ok := c.blockDefs["end"+name]; ok && end.startName == name {
+			delete(c.blockDefs, "end"+name)
+		}
+	}
 	c.tags[name] = td
 }
 
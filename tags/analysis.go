@@ -0,0 +1,182 @@
+package tags
+
+import (
+	"github.com/osteele/liquid/expressions"
+	"github.com/osteele/liquid/parser"
+	"github.com/osteele/liquid/render"
+)
+
+// Variables returns the names of the variables that a compiled template refers
+// to, in order of first reference. It omits variables that the template binds
+// before it refers to them, such as those set by assign and capture, and loop
+// variables within their loops.
+//
+// Variables knows about the standard tags. It visits the bodies of other
+// blocks, but it doesn't know about their arguments.
+func Variables(root render.Node) []string {
+	return analyze(root).names
+}
+
+// Validate reports the problems in a compiled template that would otherwise
+// only be detected when it is rendered. Currently these are references to
+// filters that aren't defined in cfg.
+//
+// Undefined tags and unterminated blocks are not reported here, since they
+// prevent the template from compiling in the first place.
+func Validate(root render.Node, cfg render.Config) []error {
+	var errs []error
+	for _, ref := range analyze(root).filters {
+		if !cfg.HasFilter(ref.name) {
+			errs = append(errs, parser.WrapError(expressions.UndefinedFilter(ref.name), ref.loc))
+		}
+	}
+	return errs
+}
+
+// analyze walks a compiled template, collecting the variables that it refers
+// to and the filters that it applies.
+func analyze(root render.Node) *templateAnalyzer {
+	c := templateAnalyzer{bound: map[string]int{}, seen: map[string]bool{}}
+	c.node(root)
+	return &c
+}
+
+type templateAnalyzer struct {
+	bound   map[string]int
+	seen    map[string]bool
+	names   []string
+	filters []filterRef
+}
+
+// filterRef records a use of a filter, for error reporting.
+type filterRef struct {
+	name string
+	loc  parser.Locatable
+}
+
+func (c *templateAnalyzer) refer(names []string) {
+	for _, name := range names {
+		if c.bound[name] == 0 && !c.seen[name] {
+			c.seen[name] = true
+			c.names = append(c.names, name)
+		}
+	}
+}
+
+func (c *templateAnalyzer) apply(names []string, loc parser.Locatable) {
+	for _, name := range names {
+		c.filters = append(c.filters, filterRef{name, loc})
+	}
+}
+
+func (c *templateAnalyzer) expr(expr expressions.Expression, loc parser.Locatable) {
+	c.refer(expressions.Variables(expr))
+	c.apply(expressions.Filters(expr), loc)
+}
+
+func (c *templateAnalyzer) exprSource(source string, loc parser.Locatable) {
+	if expr, err := expressions.Parse(source); err == nil {
+		c.expr(expr, loc)
+	}
+}
+
+// stmt parses and visits a statement, and returns nil if it doesn't parse.
+func (c *templateAnalyzer) stmt(sel, source string, loc parser.Locatable) *expressions.Statement {
+	stmt, err := expressions.ParseStatement(sel, source)
+	if err != nil {
+		return nil
+	}
+	c.refer(stmt.Variables())
+	c.apply(stmt.Filters(), loc)
+	return stmt
+}
+
+func (c *templateAnalyzer) nodes(nodes []render.Node) {
+	for _, n := range nodes {
+		c.node(n)
+	}
+}
+
+func (c *templateAnalyzer) node(n render.Node) {
+	switch n := n.(type) {
+	case *render.SeqNode:
+		c.nodes(n.Children)
+	case *render.ObjectNode:
+		c.exprSource(n.Args, n)
+	case *render.TagNode:
+		c.tag(n)
+	case *render.BlockNode:
+		c.block(n)
+	}
+}
+
+func (c *templateAnalyzer) tag(n *render.TagNode) {
+	switch n.Name {
+	case "assign":
+		if stmt := c.stmt(expressions.AssignStatementSelector, n.Args, n); stmt != nil {
+			for _, name := range stmt.Assignment.Variables {
+				c.bound[name]++
+			}
+		}
+	case "include":
+		if args, err := parseIncludeArgs(n.Args); err == nil {
+			c.expr(args.filename, n)
+			if args.with != nil {
+				c.expr(args.with, n)
+			}
+			for _, param := range args.params {
+				c.expr(param.expr, n)
+			}
+		}
+	}
+}
+
+func (c *templateAnalyzer) block(n *render.BlockNode) {
+	switch n.Name {
+	case "for", "tablerow":
+		if stmt := c.stmt(expressions.LoopStatementSelector, n.Args, n); stmt != nil {
+			c.scoped([]string{stmt.Loop.Variable, forloopVarName}, n.Body)
+		}
+		return
+	case "with":
+		if stmt := c.stmt(expressions.AssignStatementSelector, n.Args, n); stmt != nil {
+			c.scoped([]string{stmt.Assignment.Variable}, n.Body)
+		}
+		return
+	case "capture":
+		c.nodes(n.Body)
+		if name, err := captureVariable(n.Args); err == nil {
+			c.bound[name]++
+		}
+		return
+	case "content_for":
+		c.nodes(n.Body)
+		if name, err := contentForVariable(n.Args); err == nil {
+			c.bound[name]++
+		}
+		return
+	case "case", "if", "layout", "unless":
+		c.exprSource(n.Args, n)
+	}
+	c.nodes(n.Body)
+	for _, clause := range n.Clauses {
+		switch clause.Name {
+		case "elsif":
+			c.exprSource(clause.Args, clause)
+		case "when":
+			c.stmt(expressions.WhenStatementSelector, clause.Args, clause)
+		}
+		c.nodes(clause.Body)
+	}
+}
+
+// scoped visits nodes with names bound.
+func (c *templateAnalyzer) scoped(names []string, nodes []render.Node) {
+	for _, name := range names {
+		c.bound[name]++
+	}
+	c.nodes(nodes)
+	for _, name := range names {
+		c.bound[name]--
+	}
+}
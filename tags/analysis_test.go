@@ -0,0 +1,68 @@
+package tags
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/osteele/liquid/parser"
+	"github.com/osteele/liquid/render"
+	"github.com/stretchr/testify/require"
+)
+
+var variablesTests = []struct {
+	in       string
+	expected []string
+}{
+	{`text`, nil},
+	{`{{ a }}{{ b.c[d] }}{{ a | append: e }}`, []string{"a", "b", "d", "e"}},
+	{`{% if a > b %}{{ c }}{% elsif d %}{% else %}{{ e }}{% endif %}`, []string{"a", "b", "c", "d", "e"}},
+	{`{% unless a %}{{ b }}{% endunless %}`, []string{"a", "b"}},
+	{`{% if items != empty %}{{ items.first }}{% endif %}`, []string{"items"}},
+	{`{% case a %}{% when b, c %}{{ d }}{% else %}{{ e }}{% endcase %}`, []string{"a", "b", "c", "d", "e"}},
+	// loop variables are local to the loop
+	{`{% for x in xs limit: n %}{{ x }}{{ forloop.index }}{{ y }}{% endfor %}{{ x }}`, []string{"xs", "n", "y", "x"}},
+	{`{% tablerow x in xs %}{{ x }}{% endtablerow %}`, []string{"xs"}},
+	{`{% with x = a %}{{ x }}{% endwith %}{{ x }}`, []string{"a", "x"}},
+	// assigned and captured variables are bound after the tag
+	{`{{ x }}{% assign x = a %}{{ x }}{{ b }}`, []string{"x", "a", "b"}},
+	{`{% assign x = x | append: a %}{{ x }}`, []string{"x", "a"}},
+	{`{% assign x, y = pair %}{{ x }}{{ y }}`, []string{"pair"}},
+	{`{% for i in xs %}{% assign last = i %}{% endfor %}{{ last }}`, []string{"xs"}},
+	{`{% capture x %}{{ a }}{% endcapture %}{{ x }}`, []string{"a"}},
+	// other tags
+	{`{% include "file.html" with a, b: c %}`, []string{"a", "c"}},
+	{`{% include name %}`, []string{"name"}},
+	{`{% for x in xs %}{% cycle "a", "b" %}{% ifchanged %}{{ x }}{{ a }}{% endifchanged %}{% endfor %}`, []string{"xs", "a"}},
+	{`{% comment %}{{ a }}{% endcomment %}{% raw %}{{ b }}{% endraw %}`, nil},
+}
+
+func TestVariables(t *testing.T) {
+	cfg := render.NewConfig()
+	AddStandardTags(cfg)
+	for _, test := range variablesTests {
+		root, err := cfg.Compile(test.in, parser.SourceLoc{})
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, Variables(root), test.in)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := render.NewConfig()
+	AddStandardTags(cfg)
+	cfg.AddFilter("upcase", strings.ToUpper)
+	loc := parser.SourceLoc{Pathname: "template.html", LineNo: 1}
+
+	root, err := cfg.Compile(`{{ a | upcase }}{% if a | upcase %}{% endif %}`, loc)
+	require.NoError(t, err)
+	require.Empty(t, Validate(root, cfg))
+
+	root, err = cfg.Compile("{{ a | undefined1 }}\n{% for x in xs | undefined2 %}{% assign y = x | upcase | undefined1 %}{% endfor %}", loc)
+	require.NoError(t, err)
+	errs := Validate(root, cfg)
+	require.Len(t, errs, 3)
+	require.Contains(t, errs[0].Error(), `undefined filter "undefined1"`)
+	require.Contains(t, errs[0].Error(), "line 1")
+	require.Contains(t, errs[1].Error(), `undefined filter "undefined2"`)
+	require.Contains(t, errs[1].Error(), "line 2")
+	require.Contains(t, errs[2].Error(), `undefined filter "undefined1"`)
+}
@@ -0,0 +1,77 @@
+package tags
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/osteele/liquid/render"
+)
+
+// Assets holds the contents of the {% stylesheet %} and {% javascript %}
+// blocks of a template, in source order.
+type Assets struct {
+	Stylesheets []string
+	Javascripts []string
+}
+
+// CollectAssets returns the contents of the {% stylesheet %} and
+// {% javascript %} blocks of a compiled template. It doesn't visit the files
+// that the template includes.
+func CollectAssets(root render.Node) Assets {
+	var assets Assets
+	collectAssets(root, &assets)
+	return assets
+}
+
+func collectAssets(n render.Node, assets *Assets) {
+	switch n := n.(type) {
+	case *render.SeqNode:
+		for _, child := range n.Children {
+			collectAssets(child, assets)
+		}
+	case *render.BlockNode:
+		switch n.Name {
+		case "stylesheet":
+			text, _ := blockText(n)
+			assets.Stylesheets = append(assets.Stylesheets, text)
+			return
+		case "javascript":
+			text, _ := blockText(n)
+			assets.Javascripts = append(assets.Javascripts, text)
+			return
+		}
+		for _, child := range n.Body {
+			collectAssets(child, assets)
+		}
+		for _, clause := range n.Clauses {
+			collectAssets(clause, assets)
+		}
+	}
+}
+
+// assetTagCompiler compiles {% stylesheet %}…{% endstylesheet %} and
+// {% javascript %}…{% endjavascript %}. As in Shopify, the body is collected
+// (see CollectAssets) instead of being written where the block appears, and
+// Liquid in the body isn't evaluated.
+func assetTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
+	if _, err := blockText(&node); err != nil {
+		return nil, err
+	}
+	return func(io.Writer, render.Context) error { return nil }, nil
+}
+
+// blockText returns the body of an opaque block that takes no arguments, such
+// as stylesheet, javascript, and schema.
+func blockText(n *render.BlockNode) (string, error) {
+	if strings.TrimSpace(n.Args) != "" {
+		return "", fmt.Errorf("%s doesn't take arguments", n.Name)
+	}
+	var buf strings.Builder
+	for _, child := range n.Body {
+		if text, ok := child.(*render.TextNode); ok {
+			buf.WriteString(text.Source)
+		}
+	}
+	return buf.String(), nil
+}
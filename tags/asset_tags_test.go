@@ -0,0 +1,56 @@
+package tags
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/osteele/liquid/parser"
+	"github.com/osteele/liquid/render"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetTags(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+
+	root, err := config.Compile(`a{% stylesheet %}
+  .product { margin: 0; }
+{% endstylesheet %}b{% javascript %}document.title = "x";{% endjavascript %}c`, parser.SourceLoc{})
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	err = render.Render(root, buf, tagTestBindings, config)
+	require.NoError(t, err)
+	require.Equal(t, "abc", buf.String())
+
+	assets := CollectAssets(root)
+	require.Equal(t, []string{"\n  .product { margin: 0; }\n"}, assets.Stylesheets)
+	require.Equal(t, []string{`document.title = "x";`}, assets.Javascripts)
+
+	// empty blocks are collected, too
+	root, err = config.Compile(`{% for i in (1..2) %}{% javascript %}{% endjavascript %}{% endfor %}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	require.Equal(t, Assets{Javascripts: []string{""}}, CollectAssets(root))
+	require.Equal(t, Assets{}, CollectAssets(&render.SeqNode{}))
+
+	// Liquid in the body isn't evaluated
+	root, err = config.Compile(`{% stylesheet %}.a { color: {{ color }}; }{% endstylesheet %}{% javascript %}if (a) {% if %}{% endjavascript %}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	require.Equal(t, Assets{
+		Stylesheets: []string{".a { color: {{ color }}; }"},
+		Javascripts: []string{"if (a) {% if %}"},
+	}, CollectAssets(root))
+}
+
+func TestAssetTags_errors(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+
+	tests := []struct{ in, expected string }{
+		{`{% stylesheet "a" %}{% endstylesheet %}`, "doesn't take arguments"},
+	}
+	for _, test := range tests {
+		_, err := config.Compile(test.in, parser.SourceLoc{})
+		require.Errorf(t, err, test.in)
+		require.Contains(t, err.Error(), test.expected, test.in)
+	}
+}
@@ -25,6 +25,16 @@ func (c exprCase) test(caseValue interface{}, ctx render.Context) (bool, error)
 		if err != nil {
 			return false, err
 		}
+		if c.Contains {
+			if values.ValueOf(caseValue).Contains(values.ValueOf(whenValue)) {
+				return true, nil
+			}
+			continue
+		}
+		// A range matches the integers that it includes.
+		if r, ok := whenValue.(values.Range); ok && r.Includes(caseValue) {
+			return true, nil
+		}
 		if values.Equal(caseValue, whenValue) {
 			return true, nil
 		}
@@ -24,6 +24,16 @@ var cfTagTests = []struct{ in, expected string }{
 	{`{% case 1 %}{% when 1,2 %}a{% else %}b{% endcase %}`, "a"},
 	{`{% case 2 %}{% when 1,2 %}a{% else %}b{% endcase %}`, "a"},
 	{`{% case 3 %}{% when 1,2 %}a{% else %}b{% endcase %}`, "b"},
+	// range
+	{`{% case 3 %}{% when (1..5) %}a{% else %}b{% endcase %}`, "a"},
+	{`{% case 6 %}{% when (1..5) %}a{% else %}b{% endcase %}`, "b"},
+	{`{% case x %}{% when (1..5), (100..200) %}a{% else %}b{% endcase %}`, "a"},
+	{`{% case "3" %}{% when (1..5) %}a{% else %}b{% endcase %}`, "b"},
+	// strings
+	{`{% case "apple" %}{% when "pear" %}a{% when "apple" %}b{% endcase %}`, "b"},
+	{`{% case "pineapple" %}{% when contains "pear" %}a{% when contains "kiwi", "apple" %}b{% endcase %}`, "b"},
+	{`{% case "pineapple" %}{% when contains "pear" %}a{% else %}b{% endcase %}`, "b"},
+	{`{% case animals %}{% when contains "giraffe" %}a{% else %}b{% endcase %}`, "a"},
 
 	// if
 	{`{% if true %}true{% endif %}`, "true"},
@@ -40,6 +50,28 @@ var cfTagTests = []struct{ in, expected string }{
 	{`{% if false %}0{% elsif true %}1{% else %}2{% endif %}`, "1"},
 	{`{% if false %}0{% elsif false %}1{% else %}2{% endif %}`, "2"},
 
+	// an empty array is truthy, but equal to empty
+	{`{% if empty_array %}a{% endif %}`, "a"},
+	{`{% if empty_array == empty %}a{% endif %}{% if y == empty %}b{% endif %}`, "a"},
+	{`{% if pair == empty %}a{% else %}b{% endif %}`, "b"},
+	{`{% unless empty_array != empty %}a{% endunless %}`, "a"},
+	{`{{ empty }}`, ""},
+
+	// comparison operators
+	{`{% if x >= 123 %}a{% endif %}{% if x >= 124 %}b{% endif %}`, "a"},
+	{`{% if x <= 123 %}a{% endif %}{% if x <= 122 %}b{% endif %}`, "a"},
+	{`{% if x != 123 %}a{% endif %}{% if x != 1 %}b{% endif %}`, "b"},
+	{`{% if x <> 123 %}a{% endif %}{% if x <> 1 %}b{% endif %}`, "b"},
+	{`{% if "b" >= "a" %}a{% endif %}{% if "b" <= "a" %}b{% endif %}`, "a"},
+	{`{% if "a" != "a" %}a{% endif %}{% if "a" <> "b" %}b{% endif %}`, "b"},
+	{`{% unless x <> 123 %}a{% endunless %}`, "a"},
+
+	// contains
+	{`{% if obj contains "a" %}a{% endif %}{% if obj contains "b" %}b{% endif %}`, "a"},
+	{`{% unless obj contains "b" %}a{% endunless %}`, "a"},
+	{`{% if pair contains "first" %}a{% endif %}{% if pair contains "a" %}b{% endif %}`, "a"},
+	{`{% if "pineapple" contains "apple" %}a{% endif %}`, "a"},
+
 	// unless
 	{`{% unless true %}false{% endunless %}`, ""},
 	{`{% unless false %}true{% endunless %}`, "true"},
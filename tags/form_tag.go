@@ -0,0 +1,178 @@
+package tags
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/osteele/liquid/expressions"
+	"github.com/osteele/liquid/render"
+	"github.com/osteele/liquid/values"
+)
+
+// defaultFormTypes are the form types that {% form %} supports unless
+// Config.FormTypes replaces them.
+var defaultFormTypes = map[string]render.FormType{
+	"contact":         {Action: "/contact#contact_form", ID: "contact_form", Class: "contact-form"},
+	"create_customer": {Action: "/account", ID: "create_customer"},
+	"customer_login":  {Action: "/account/login", ID: "customer_login"},
+	"product": {Action: "/cart/add", ID: "product_form", Class: "shopify-product-form",
+		Enctype: "multipart/form-data"},
+	"recover_customer_password": {Action: "/account/recover", ID: "recover_customer_password"},
+}
+
+// formAttrRe matches a name: value argument. Unlike include parameters, the
+// names are HTML attributes, so they can contain hyphens.
+var formAttrRe = regexp.MustCompile(`^\s*([\w-]+)\s*:\s*(.+?)\s*$`)
+
+// formArgs is a parse of the arguments to {% form %}:
+//
+//	{% form "type"[, object] [, name: expr]… %}
+type formArgs struct {
+	formType expressions.Expression
+	object   expressions.Expression
+	attrs    []includeParam
+}
+
+func parseFormArgs(source string) (*formArgs, error) {
+	var args formArgs
+	for i, s := range splitOutsideQuotes(source, includeCommaRe) {
+		if m := formAttrRe.FindStringSubmatch(s); i > 0 && m != nil {
+			expr, err := expressions.Parse(m[2])
+			if err != nil {
+				return nil, err
+			}
+			args.attrs = append(args.attrs, includeParam{m[1], expr})
+			continue
+		}
+		expr, err := expressions.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case i == 0:
+			args.formType = expr
+		case i == 1:
+			args.object = expr
+		default:
+			return nil, fmt.Errorf("syntax error in form arguments %q", source)
+		}
+	}
+	return &args, nil
+}
+
+// formTagCompiler compiles {% form "type" %}…{% endform %}. It writes a form
+// element, whose action and attributes depend on the form type, around the
+// body. Within the body, form holds the form's type and id.
+func formTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
+	args, err := parseFormArgs(node.Args)
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer, ctx render.Context) error {
+		value, err := ctx.Evaluate(args.formType)
+		if err != nil {
+			return err
+		}
+		name, ok := value.(string)
+		if !ok {
+			return ctx.Errorf("form requires a string argument; got %v", value)
+		}
+		ft, ok := ctx.Config().FormTypes[name]
+		if !ok {
+			if ft, ok = defaultFormTypes[name]; !ok {
+				return ctx.Errorf("unknown form type %q", name)
+			}
+		}
+		id := ft.ID
+		if args.object != nil {
+			object, err := ctx.Evaluate(args.object)
+			if err != nil {
+				return err
+			}
+			if oid := values.ValueOf(object).PropertyValue(values.ValueOf("id")).Interface(); oid != nil {
+				id = fmt.Sprintf("%s_%v", id, oid)
+			}
+		}
+		attrs := map[string]string{
+			"method":         "post",
+			"action":         ft.Action,
+			"id":             id,
+			"accept-charset": "UTF-8",
+			"class":          ft.Class,
+			"enctype":        ft.Enctype,
+		}
+		for _, attr := range args.attrs {
+			value, err := ctx.Evaluate(attr.expr)
+			if err != nil {
+				return err
+			}
+			attrs[attr.name] = fmt.Sprint(value)
+		}
+		fields := map[string]string{"form_type": name, "utf8": "✓"}
+		for k, v := range ft.Fields {
+			fields[k] = v
+		}
+		if _, err := io.WriteString(w, formOpenTag(attrs, fields)); err != nil {
+			return err
+		}
+		defer func(prev interface{}) { ctx.Set("form", prev) }(ctx.Get("form"))
+		ctx.Set("form", map[string]interface{}{
+			"type":                 name,
+			"id":                   id,
+			"errors":               nil,
+			"posted_successfully?": false,
+		})
+		if err := ctx.RenderChildren(w); err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "</form>")
+		return err
+	}, nil
+}
+
+// formAttrOrder is the order in which formOpenTag writes the standard
+// attributes. Others follow in order of name.
+var formAttrOrder = []string{"method", "action", "id", "accept-charset", "class", "enctype"}
+
+// formOpenTag returns a form start tag, followed by hidden inputs for fields.
+// The form_type and utf8 fields come first. Empty attributes are omitted.
+func formOpenTag(attrs, fields map[string]string) string {
+	var buf strings.Builder
+	buf.WriteString("<form")
+	write := func(k string) {
+		if v := attrs[k]; v != "" {
+			fmt.Fprintf(&buf, ` %s="%s"`, k, html.EscapeString(v))
+		}
+		delete(attrs, k)
+	}
+	for _, k := range formAttrOrder {
+		write(k)
+	}
+	for _, k := range sortedKeys(attrs) {
+		write(k)
+	}
+	buf.WriteString(">")
+	names := []string{"form_type", "utf8"}
+	for _, k := range sortedKeys(fields) {
+		if k != "form_type" && k != "utf8" {
+			names = append(names, k)
+		}
+	}
+	for _, k := range names {
+		fmt.Fprintf(&buf, `<input type="hidden" name="%s" value="%s" />`, html.EscapeString(k), html.EscapeString(fields[k]))
+	}
+	return buf.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
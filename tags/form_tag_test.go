@@ -0,0 +1,68 @@
+package tags
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/osteele/liquid/parser"
+	"github.com/osteele/liquid/render"
+	"github.com/stretchr/testify/require"
+)
+
+const contactFormOpenTag = `<form method="post" action="/contact#contact_form" id="contact_form" accept-charset="UTF-8" class="contact-form">` +
+	`<input type="hidden" name="form_type" value="contact" /><input type="hidden" name="utf8" value="✓" />`
+
+var formTagTests = []struct{ in, expected string }{
+	{`{% form "contact" %}<input name="email">{% endform %}`, contactFormOpenTag + `<input name="email"></form>`},
+	{`{% form 'contact' %}{{ form.type }} {{ form.id }} {{ form.posted_successfully? }}{% endform %}{{ form }}`,
+		contactFormOpenTag + `contact contact_form false</form>`},
+	{`{% form "contact", class: "wide" %}{% endform %}`,
+		`<form method="post" action="/contact#contact_form" id="contact_form" accept-charset="UTF-8" class="wide">` +
+			`<input type="hidden" name="form_type" value="contact" /><input type="hidden" name="utf8" value="✓" /></form>`},
+	{`{% form "contact", data-x: var, id: "c" %}{% endform %}`,
+		`<form method="post" action="/contact#contact_form" id="c" accept-charset="UTF-8" class="contact-form" data-x="value">` +
+			`<input type="hidden" name="form_type" value="contact" /><input type="hidden" name="utf8" value="✓" /></form>`},
+	{`{% form "product", product %}{{ form.id }}{% endform %}`,
+		`<form method="post" action="/cart/add" id="product_form_123" accept-charset="UTF-8" class="shopify-product-form" enctype="multipart/form-data">` +
+			`<input type="hidden" name="form_type" value="product" /><input type="hidden" name="utf8" value="✓" />product_form_123</form>`},
+	{`{% form "newsletter" %}{% endform %}`,
+		`<form method="post" action="/subscribe" id="newsletter" accept-charset="UTF-8">` +
+			`<input type="hidden" name="form_type" value="newsletter" /><input type="hidden" name="utf8" value="✓" />` +
+			`<input type="hidden" name="list" value="a&amp;b" /></form>`},
+}
+
+var formTagErrorTests = []struct{ in, expected string }{
+	{`{% form "unknown" %}{% endform %}`, "unknown form type"},
+	{`{% form 1 %}{% endform %}`, "requires a string"},
+	{`{% form "contact", a, b %}{% endform %}`, "syntax error"},
+}
+
+func TestFormTag(t *testing.T) {
+	config := render.NewConfig()
+	config.FormTypes = map[string]render.FormType{
+		"newsletter": {Action: "/subscribe", ID: "newsletter", Fields: map[string]string{"list": "a&b"}},
+	}
+	AddStandardTags(config)
+	bindings := map[string]interface{}{
+		"var":     "value",
+		"product": map[string]interface{}{"id": 123},
+	}
+
+	for _, test := range formTagTests {
+		root, err := config.Compile(test.in, parser.SourceLoc{})
+		require.NoErrorf(t, err, test.in)
+		buf := new(bytes.Buffer)
+		err = render.Render(root, buf, bindings, config)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, buf.String(), test.in)
+	}
+	for _, test := range formTagErrorTests {
+		root, err := config.Compile(test.in, parser.SourceLoc{})
+		if err == nil {
+			err = render.Render(root, ioutil.Discard, bindings, config)
+		}
+		require.Errorf(t, err, test.in)
+		require.Containsf(t, err.Error(), test.expected, test.in)
+	}
+}
@@ -1,27 +1,137 @@
 package tags
 
 import (
+	"fmt"
 	"io"
 	"path/filepath"
+	"regexp"
+	"strings"
 
+	"github.com/osteele/liquid/expressions"
 	"github.com/osteele/liquid/render"
 )
 
+// includeArgs is a parse of the arguments to {% include %}:
+//
+//	{% include filename [with expr] [, name: expr]… %}
+type includeArgs struct {
+	filename expressions.Expression
+	with     expressions.Expression
+	params   []includeParam
+}
+
+type includeParam struct {
+	name string
+	expr expressions.Expression
+}
+
+var (
+	includeCommaRe = regexp.MustCompile(`,`)
+	includeParamRe = regexp.MustCompile(`^\s*(\w+)\s*:\s*(.+?)\s*$`)
+	includeWithRe  = regexp.MustCompile(`\s+with\s+`)
+)
+
+func parseIncludeArgs(source string) (*includeArgs, error) {
+	var args includeArgs
+	// Split on commas that introduce a name: value parameter. Other commas,
+	// such as those between filter arguments, belong to the preceding part.
+	parts := []string{}
+	for _, s := range splitOutsideQuotes(source, includeCommaRe) {
+		if len(parts) > 0 && !includeParamRe.MatchString(s) {
+			parts[len(parts)-1] += "," + s
+			continue
+		}
+		parts = append(parts, s)
+	}
+	head := splitOutsideQuotes(parts[0], includeWithRe)
+	if len(head) > 2 {
+		return nil, fmt.Errorf("syntax error in include arguments %q", source)
+	}
+	expr, err := expressions.Parse(head[0])
+	if err != nil {
+		return nil, err
+	}
+	args.filename = expr
+	if len(head) == 2 {
+		if args.with, err = expressions.Parse(head[1]); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range parts[1:] {
+		m := includeParamRe.FindStringSubmatch(s)
+		expr, err := expressions.Parse(m[2])
+		if err != nil {
+			return nil, err
+		}
+		args.params = append(args.params, includeParam{m[1], expr})
+	}
+	return &args, nil
+}
+
+// splitOutsideQuotes splits s around the matches of sep that are not within a
+// quoted string.
+func splitOutsideQuotes(s string, sep *regexp.Regexp) []string {
+	var (
+		quoted = make([]bool, len(s))
+		quote  byte
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			quoted[i] = true
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quoted[i] = true
+			quote = c
+		}
+	}
+	var (
+		parts []string
+		p     int
+	)
+	for _, m := range sep.FindAllStringIndex(s, -1) {
+		if !quoted[m[0]] {
+			parts = append(parts, s[p:m[0]])
+			p = m[1]
+		}
+	}
+	return append(parts, s[p:])
+}
+
 func includeTag(source string) (func(io.Writer, render.Context) error, error) {
+	args, err := parseIncludeArgs(source)
+	if err != nil {
+		return nil, err
+	}
 	return func(w io.Writer, ctx render.Context) error {
 		// It might be more efficient to add a context interface to render bytes
 		// to a writer. The status quo keeps the interface light at the expense of some overhead
 		// here.
-		value, err := ctx.EvaluateString(ctx.TagArgs())
+		filename, err := templateFilename(ctx, args.filename)
 		if err != nil {
 			return err
 		}
-		rel, ok := value.(string)
-		if !ok {
-			return ctx.Errorf("include requires a string argument; got %v", value)
+		bindings := map[string]interface{}{}
+		if args.with != nil {
+			// {% include "product" with expr %} binds expr to the variable
+			// named after the file, as in Shopify.
+			value, err := ctx.Evaluate(args.with)
+			if err != nil {
+				return err
+			}
+			name := filepath.Base(filename)
+			bindings[strings.TrimSuffix(name, filepath.Ext(name))] = value
+		}
+		for _, param := range args.params {
+			value, err := ctx.Evaluate(param.expr)
+			if err != nil {
+				return err
+			}
+			bindings[param.name] = value
 		}
-		filename := filepath.Join(filepath.Dir(ctx.SourceFile()), rel)
-		s, err := ctx.RenderFile(filename, map[string]interface{}{})
+		s, err := ctx.RenderFile(filename, bindings)
 		if err != nil {
 			return err
 		}
@@ -29,3 +139,25 @@ func includeTag(source string) (func(io.Writer, render.Context) error, error) {
 		return err
 	}, nil
 }
+
+// templateFilename evaluates the filename argument to a tag such as include or
+// layout, and resolves it relative to the directory of the current template.
+func templateFilename(ctx render.Context, expr expressions.Expression) (string, error) {
+	value, err := ctx.Evaluate(expr)
+	if err != nil {
+		return "", err
+	}
+	rel, ok := value.(string)
+	if !ok {
+		return "", ctx.Errorf("%s requires a string argument; got %v", ctx.TagName(), value)
+	}
+	// Relative filenames are relative to the including file. Absolute
+	// filenames are used as is, unless there's a template root.
+	if filepath.IsAbs(rel) || strings.HasPrefix(rel, "/") || strings.HasPrefix(rel, `\`) {
+		if ctx.Config().TemplateRoot != "" {
+			return "", ctx.Errorf("%s requires a relative filename; got %q", ctx.TagName(), rel)
+		}
+		return rel, nil
+	}
+	return filepath.Join(filepath.Dir(ctx.SourceFile()), rel), nil
+}
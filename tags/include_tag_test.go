@@ -2,8 +2,10 @@ package tags
 
 import (
 	"bytes"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -15,6 +17,18 @@ import (
 var includeTestBindings = map[string]interface{}{
 	"test": true,
 	"var":  "value",
+	"item": map[string]interface{}{"title": "Shirt"},
+}
+
+var includeParamTests = []struct{ in, expected string }{
+	{`{% include "product.html" with item %}`, "Shirt"},
+	{`{% include 'product.html' with item %}`, "Shirt"},
+	{`{% include "product.html" with item, color: "red" %}`, "Shirt/red"},
+	{`{% include "product.html" with item, color: var %}`, "Shirt/value"},
+	{`{% include "product.html", product: item, color: "a, b" %}`, "Shirt/a, b"},
+	{`{% include "product.html", color: "red" %}`, "/red"},
+	{`{% include "product.html" %}`, ""},
+	{`{% include "product.html" with item %}{{ product }}{{ color }}`, "Shirt"},
 }
 
 func TestIncludeTag(t *testing.T) {
@@ -46,6 +60,174 @@ func TestIncludeTag(t *testing.T) {
 	require.Contains(t, err.Error(), "requires a string")
 }
 
+func TestIncludeTag_parameters(t *testing.T) {
+	config := render.NewConfig()
+	loc := parser.SourceLoc{Pathname: "testdata/include_source.html", LineNo: 1}
+	AddStandardTags(config)
+
+	for _, test := range includeParamTests {
+		root, err := config.Compile(test.in, loc)
+		require.NoErrorf(t, err, test.in)
+		buf := new(bytes.Buffer)
+		err = render.Render(root, buf, includeTestBindings, config)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, strings.TrimSpace(buf.String()), test.in)
+	}
+
+	// syntax errors are reported at compile time
+	_, err := config.Compile(`{% include "product.html" with %}`, loc)
+	require.Error(t, err)
+	_, err = config.Compile(`{% include "product.html", color: syntax error %}`, loc)
+	require.Error(t, err)
+}
+
+func TestIncludeTag_override(t *testing.T) {
+	config := render.NewConfig()
+	loc := parser.SourceLoc{Pathname: "testdata/include_source.html", LineNo: 1}
+	AddStandardTags(config)
+	config.AddTag("include", func(source string) (func(io.Writer, render.Context) error, error) {
+		return func(w io.Writer, ctx render.Context) error {
+			_, err := io.WriteString(w, "custom include "+source)
+			return err
+		}, nil
+	})
+
+	root, err := config.Compile(`{% include "include_target.html" %}`, loc)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	err = render.Render(root, buf, includeTestBindings, config)
+	require.NoError(t, err)
+	require.Equal(t, `custom include "include_target.html"`, buf.String())
+
+	// other configurations still use the standard include tag
+	standard := render.NewConfig()
+	AddStandardTags(standard)
+	root, err = standard.Compile(`{% include "include_target.html" %}`, loc)
+	require.NoError(t, err)
+	buf = new(bytes.Buffer)
+	err = render.Render(root, buf, includeTestBindings, standard)
+	require.NoError(t, err)
+	require.Equal(t, "include target", strings.TrimSpace(buf.String()))
+}
+
+func TestIncludeTag_relative(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+
+	// posts/a.html includes ../shared/nav.html, which includes links.html from
+	// its own directory.
+	loc := parser.SourceLoc{Pathname: "testdata/posts/index.html", LineNo: 1}
+	root, err := config.Compile(`{% include "a.html" %}`, loc)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	err = render.Render(root, buf, includeTestBindings, config)
+	require.NoError(t, err)
+	require.Equal(t, "nav: links", strings.TrimSpace(buf.String()))
+}
+
+func TestIncludeTag_TemplateRoot(t *testing.T) {
+	config := render.NewConfig()
+	config.TemplateRoot = "testdata/posts"
+	AddStandardTags(config)
+	loc := parser.SourceLoc{Pathname: "testdata/posts/index.html", LineNo: 1}
+
+	root, err := config.Compile(`{% include "../include_target.html" %}`, loc)
+	require.NoError(t, err)
+	err = render.Render(root, ioutil.Discard, includeTestBindings, config)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "outside the template root")
+
+	// a relative include within the root is allowed, but its own include of
+	// a file outside the root is not
+	root, err = config.Compile(`{% include "../posts/a.html" %}`, loc)
+	require.NoError(t, err)
+	err = render.Render(root, ioutil.Discard, includeTestBindings, config)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "outside the template root")
+
+	config.TemplateRoot = "testdata"
+	root, err = config.Compile(`{% include "a.html" %}`, loc)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	err = render.Render(root, buf, includeTestBindings, config)
+	require.NoError(t, err)
+	require.Equal(t, "nav: links", strings.TrimSpace(buf.String()))
+}
+
+func TestIncludeTag_TemplateRoot_disallowed(t *testing.T) {
+	config := render.NewConfig()
+	config.TemplateRoot = "testdata"
+	AddStandardTags(config)
+	loc := parser.SourceLoc{Pathname: "testdata/include_source.html", LineNo: 1}
+
+	root, err := config.Compile(`{% include "include_target.html" %}`, loc)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	err = render.Render(root, buf, includeTestBindings, config)
+	require.NoError(t, err)
+	require.Equal(t, "include target", strings.TrimSpace(buf.String()))
+
+	tests := []struct{ filename, expected string }{
+		{"/etc/passwd", "requires a relative filename"},
+		{`\\server\\share`, "requires a relative filename"},
+		{"../../x", "outside the template root"},
+		{"posts/../../x", "outside the template root"},
+		{"..", "outside the template root"},
+	}
+	for _, test := range tests {
+		root, err := config.Compile(`{% include "`+test.filename+`" %}`, loc)
+		require.NoError(t, err, test.filename)
+		err = render.Render(root, ioutil.Discard, includeTestBindings, config)
+		require.Error(t, err, test.filename)
+		require.Contains(t, err.Error(), test.expected, test.filename)
+	}
+}
+
+func TestIncludeTag_TemplateRoot_symlink(t *testing.T) {
+	dir := t.TempDir()
+	templates := filepath.Join(dir, "templates")
+	require.NoError(t, os.Mkdir(templates, 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "secret.html"), []byte("secret"), 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(templates, "page.html"), []byte("page"), 0o644))
+	if err := os.Symlink(filepath.Join(dir, "secret.html"), filepath.Join(templates, "link.html")); err != nil {
+		t.Skip("symbolic links aren't supported:", err)
+	}
+	require.NoError(t, os.Symlink(templates, filepath.Join(dir, "root")))
+
+	config := render.NewConfig()
+	config.TemplateRoot = filepath.Join(dir, "root")
+	AddStandardTags(config)
+	loc := parser.SourceLoc{Pathname: filepath.Join(templates, "index.html"), LineNo: 1}
+
+	// the root may itself be a link
+	root, err := config.Compile(`{% include "page.html" %}`, loc)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	require.NoError(t, render.Render(root, buf, includeTestBindings, config))
+	require.Equal(t, "page", buf.String())
+
+	root, err = config.Compile(`{% include "link.html" %}`, loc)
+	require.NoError(t, err)
+	err = render.Render(root, ioutil.Discard, includeTestBindings, config)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "outside the template root")
+}
+
+func TestIncludeTag_absolute(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+	loc := parser.SourceLoc{Pathname: "testdata/posts/index.html", LineNo: 1}
+	filename, err := filepath.Abs("testdata/include_target.html")
+	require.NoError(t, err)
+
+	// without a template root, absolute filenames are allowed
+	root, err := config.Compile(`{% include "`+filepath.ToSlash(filename)+`" %}`, loc)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	require.NoError(t, render.Render(root, buf, includeTestBindings, config))
+	require.Equal(t, "include target", strings.TrimSpace(buf.String()))
+}
+
 func TestIncludeTag_file_not_found_error(t *testing.T) {
 	config := render.NewConfig()
 	loc := parser.SourceLoc{Pathname: "testdata/include_source.html", LineNo: 1}
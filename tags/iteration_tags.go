@@ -11,6 +11,7 @@ import (
 
 	"github.com/osteele/liquid/expressions"
 	"github.com/osteele/liquid/render"
+	"github.com/osteele/liquid/values"
 )
 
 // An IterationKeyedMap is a map that yields its keys, instead of (key, value) pairs, when iterated.
@@ -18,6 +19,10 @@ type IterationKeyedMap map[string]interface{}
 
 const forloopVarName = "forloop"
 
+// ifchangedVarName holds the previous output of {% ifchanged %}. The leading
+// period keeps templates from referring to it.
+const ifchangedVarName = ".ifchanged"
+
 var errLoopContinueLoop = fmt.Errorf("continue outside a loop")
 var errLoopBreak = fmt.Errorf("break outside a loop")
 
@@ -62,6 +67,25 @@ func cycleTag(args string) (func(io.Writer, render.Context) error, error) {
 	}, nil
 }
 
+// ifchangedTagCompiler compiles {% ifchanged %}…{% endifchanged %}, which
+// writes its body only if the output differs from the previous time it was
+// rendered. As in Shopify, all the ifchanged blocks in a render share this
+// state.
+func ifchangedTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
+	return func(w io.Writer, ctx render.Context) error {
+		s, err := ctx.InnerString()
+		if err != nil {
+			return err
+		}
+		if prev, ok := ctx.Get(ifchangedVarName).(string); ok && prev == s {
+			return nil
+		}
+		ctx.Set(ifchangedVarName, s)
+		_, err = io.WriteString(w, s)
+		return err
+	}, nil
+}
+
 func loopTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
 	stmt, err := expressions.ParseStatement(expressions.LoopStatementSelector, node.Args)
 	if err != nil {
@@ -96,14 +120,18 @@ func (loop loopRenderer) render(w io.Writer, ctx render.Context) error {
 		return err
 	}
 
-	// shallow-bind the loop variables; restore on exit
-	defer func(index, forloop interface{}) {
-		ctx.Set(forloopVarName, index)
-		ctx.Set(loop.Variable, forloop)
+	// Shallow-bind the loop variables; restore on exit. Other variables that
+	// are assigned within the loop remain visible after it, as in Shopify.
+	defer func(forloop, item interface{}) {
+		ctx.Set(forloopVarName, forloop)
+		ctx.Set(loop.Variable, item)
 	}(ctx.Get(forloopVarName), ctx.Get(loop.Variable))
 	cycleMap := map[string]int{}
 loop:
 	for i, len := 0, iter.Len(); i < len; i++ {
+		if err := ctx.CountIteration(); err != nil {
+			return err
+		}
 		ctx.Set(loop.Variable, iter.Index(i))
 		ctx.Set(forloopVarName, map[string]interface{}{
 			"first":   i == 0,
@@ -242,11 +270,17 @@ func makeIterator(value interface{}) iterable {
 	case reflect.Array, reflect.Slice:
 		return sliceWrapper(reflect.ValueOf(value))
 	case reflect.Map:
+		// Sort the keys, so that iteration order is deterministic.
 		rv := reflect.ValueOf(value)
-		array := make([][]interface{}, rv.Len())
-		for i, k := range rv.MapKeys() {
-			v := rv.MapIndex(k)
-			array[i] = []interface{}{k.Interface(), v.Interface()}
+		keys := make([]interface{}, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.Interface())
+		}
+		values.Sort(keys)
+		array := make([][]interface{}, len(keys))
+		for i, k := range keys {
+			v := rv.MapIndex(reflect.ValueOf(k))
+			array[i] = []interface{}{k, v.Interface()}
 		}
 		return sliceWrapper(reflect.ValueOf(array))
 	default:
@@ -2,6 +2,7 @@ package tags
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"regexp"
@@ -24,6 +25,11 @@ var iterationTests = []struct{ in, expected string }{
 	{`{% for a in map %}{{ a[0] }}={{ a[1] }}.{% endfor %}`, "a=1."},
 	{`{% for a in map_slice %}{{ a[0] }}={{ a[1] }}.{% endfor %}`, "a=1.b=2."},
 	{`{% for k in keyed_map %}{{ k }}={{ keyed_map[k] }}.{% endfor %}`, "a=1.b=2."},
+	{`{% for a in map %}{{ a.first }}={{ a.last }}.{% endfor %}`, "a=1."},
+	{`{% for a in large_map %}{{ a[0] }}={{ a[1] }}.{% endfor %}`, "a=1.b=2.c=3.d=4.e=5."},
+	{`{% for a in large_map %}{{ a.first }}={{ a.last }}.{% endfor %}`, "a=1.b=2.c=3.d=4.e=5."},
+	{`{% for a in large_map reversed limit: 2 %}{{ a.first }}.{% endfor %}`, "e.d."},
+	{`{% for a in int_keyed_map %}{{ a[0] }}={{ a[1] }}.{% endfor %}`, "1=one.2=two.10=ten."},
 
 	// loop modifiers
 	{`{% for a in array reversed %}{{ a }}.{% endfor %}`, "third.second.first."},
@@ -52,6 +58,15 @@ var iterationTests = []struct{ in, expected string }{
 	{`{% for a in array %}{{ forloop.rindex }}.{% endfor %}`, "3.2.1."},
 	{`{% for a in array %}{{ forloop.rindex0 }}.{% endfor %}`, "2.1.0."},
 	{`{% for a in array %}{{ forloop.length }}.{% endfor %}`, "3.3.3."},
+	{`{% for a in (1..5) %}{{ forloop.length }}.{% endfor %}`, "5.5.5.5.5."},
+	{`{% for a in (1..5) limit: 2 %}{{ forloop.length }}.{% endfor %}`, "2.2."},
+	{`{% for a in (1..5) offset: 3 %}{{ forloop.length }}.{% endfor %}`, "2.2."},
+	{`{% for a in (1..5) offset: 1 limit: 2 %}{{ a }}:{{ forloop.rindex }}/{{ forloop.length }}.{% endfor %}`, "2:2/2.3:1/2."},
+	{`{% for a in array offset: 1 %}{{ forloop.length }}{{ forloop.last }}.{% endfor %}`, "2false.2true."},
+	{`{% for a in array limit: 10 %}{{ forloop.length }}.{% endfor %}`, "3.3.3."},
+	{`{% for a in (3..1) %}{{ a }}.{% endfor %}`, ""},
+	{`{% for a in lazy %}{{ a }}/{{ forloop.length }}.{% endfor %}`, "0/3.10/3.20/3."},
+	{`{% for a in lazy offset: 2 %}{{ a }}/{{ forloop.length }}.{% endfor %}`, "20/1."},
 
 	{`{% for i in array %}{{ forloop.index }}[{% for j in array %}{{ forloop.index }}{% endfor %}]{{ forloop.index }}{% endfor %}`,
 		"1[123]12[123]23[123]3"},
@@ -77,11 +92,26 @@ var iterationTests = []struct{ in, expected string }{
 	{`{% for a in array %}{% if a == 'second' %}{% break %}{% endif %}{{ a }}{% endfor %}`, "first"},
 	{`{% for a in array %}{% if a == 'second' %}{% continue %}{% endif %}{{ a }}.{% endfor %}`, "first.third."},
 
+	// scope
+	{`{% for a in array %}{% assign last = a %}{% endfor %}{{ last }}`, "third"},
+	{`{% for a in array %}{% assign b = a %}{% break %}{% endfor %}{{ b }}`, "first"},
+	{`{% for a in array %}{% endfor %}{{ a }}`, ""},
+	{`{% for a in array %}{% endfor %}{{ forloop.index }}`, ""},
+	{`{% assign a = "outer" %}{% for a in array %}{{ a }}.{% endfor %}{{ a }}`, "first.second.third.outer"},
+	{`{% for a in array %}{% for b in array %}{% assign c = b %}{% endfor %}{% endfor %}{{ b }}{{ c }}`, "third"},
+
 	// cycle
 	{`{% for a in array %}{% cycle 'even', 'odd' %}.{% endfor %}`, "even.odd.even."},
 	{`{% for a in array %}{% cycle '0', '1' %},{% cycle '0', '1' %}.{% endfor %}`, "0,1.0,1.0,1."},
 	// {`{% for a in array %}{% cycle group: 'a', '0', '1' %},{% cycle '0', '1' %}.{% endfor %}`, "0,1.0,1.0,1."},
 
+	// ifchanged
+	{`{% for a in dup_array %}{% ifchanged %}{{ a }}{% endifchanged %}{% endfor %}`, "abca"},
+	{`{% for a in dup_array %}{% ifchanged %}<{{ a }}>{% endifchanged %}.{% endfor %}`, "<a>..<b>.<c>..<a>."},
+	{`{% for a in array %}{% ifchanged %}x{% endifchanged %}{% endfor %}`, "x"},
+	{`{% for a in dup_array %}{% ifchanged %}{{ a }}{% endifchanged %}{% endfor %}{% ifchanged %}a{% endifchanged %}`, "abca"},
+	{`{% ifchanged %}a{% endifchanged %}{% ifchanged %}a{% endifchanged %}{% ifchanged %}b{% endifchanged %}`, "ab"},
+
 	// range
 	{`{% for i in (3 .. 5) %}{{i}}.{% endfor %}`, "3.4.5."},
 	{`{% for i in (3..5) %}{{i}}.{% endfor %}`, "3.4.5."},
@@ -128,12 +158,21 @@ var iterationErrorTests = []struct{ in, expected string }{
 	{`{% for a in array %}{{ a | undefined_filter }}{% endfor %}`, "undefined filter"},
 }
 
+// multiplesOfTen is an iterable that computes its items on demand.
+type multiplesOfTen int
+
+func (n multiplesOfTen) Len() int                { return int(n) }
+func (n multiplesOfTen) Index(i int) interface{} { return i * 10 }
+
 var iterationTestBindings = map[string]interface{}{
-	"array": []string{"first", "second", "third"},
-	// hash has only one element, since iteration order is non-deterministic
-	"map":       map[string]interface{}{"a": 1},
-	"keyed_map": IterationKeyedMap(map[string]interface{}{"a": 1, "b": 2}),
-	"map_slice": yaml.MapSlice{{Key: "a", Value: 1}, {Key: "b", Value: 2}},
+	"array":         []string{"first", "second", "third"},
+	"dup_array":     []string{"a", "a", "b", "c", "c", "a"},
+	"map":           map[string]interface{}{"a": 1},
+	"large_map":     map[string]interface{}{"c": 3, "e": 5, "a": 1, "d": 4, "b": 2},
+	"int_keyed_map": map[int]string{10: "ten", 2: "two", 1: "one"},
+	"keyed_map":     IterationKeyedMap(map[string]interface{}{"a": 1, "b": 2}),
+	"map_slice":     yaml.MapSlice{{Key: "a", Value: 1}, {Key: "b", Value: 2}},
+	"lazy":          multiplesOfTen(3),
 	"products": []string{
 		"Cool Shirt", "Alien Poster", "Batman Poster", "Bullseye Shirt", "Another Classic Vinyl", "Awesome Jeans",
 	},
@@ -186,3 +225,36 @@ func TestIterationTags_errors(t *testing.T) {
 		})
 	}
 }
+
+func TestIterationTags_maxIterations(t *testing.T) {
+	cfg := render.NewConfig()
+	AddStandardTags(cfg)
+	cfg.MaxIterations = 10
+
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{`{% for i in (1..10) %}{{ i }}{% endfor %}`, true},
+		{`{% for i in (1..11) %}{{ i }}{% endfor %}`, false},
+		{`{% for i in (1..5) %}{% endfor %}{% for i in (1..5) %}{% endfor %}`, true},
+		{`{% for i in (1..5) %}{% endfor %}{% tablerow i in (1..6) %}{% endtablerow %}`, false},
+		// nested loops count cumulatively: 3 + 3×3
+		{`{% for i in (1..3) %}{% for j in (1..3) %}{% endfor %}{% endfor %}`, false},
+		{`{% for i in (1..100) %}{% break %}{% endfor %}`, true},
+	}
+	for _, test := range tests {
+		root, err := cfg.Compile(test.in, parser.SourceLoc{})
+		require.NoErrorf(t, err, test.in)
+		err = render.Render(root, ioutil.Discard, iterationTestBindings, cfg)
+		if test.ok {
+			require.NoErrorf(t, err, test.in)
+			continue
+		}
+		require.Errorf(t, err, test.in)
+		require.Containsf(t, err.Error(), "exceeded the limit of 10 loop iterations", test.in)
+		var le *render.LimitError
+		require.Truef(t, errors.As(err, &le), test.in)
+		require.Equal(t, "MaxIterations", le.Limit)
+	}
+}
@@ -0,0 +1,92 @@
+package tags
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/osteele/liquid/expressions"
+	"github.com/osteele/liquid/render"
+)
+
+// layoutTagCompiler compiles {% layout "theme.html" %}…{% endlayout %}.
+//
+// The body is rendered first. The layout file is then rendered with the
+// body's output bound to content_for_layout (as in Shopify) and to content (as
+// in Jekyll). Variables that the body sets, including those captured by
+// content_for, are visible to the layout.
+func layoutTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
+	expr, err := expressions.Parse(node.Args)
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer, ctx render.Context) error {
+		filename, err := templateFilename(ctx, expr)
+		if err != nil {
+			return err
+		}
+		content, err := ctx.InnerString()
+		if err != nil {
+			return err
+		}
+		s, err := ctx.RenderFile(filename, map[string]interface{}{
+			"content":            content,
+			"content_for_layout": content,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, s)
+		return err
+	}, nil
+}
+
+// contentForTagCompiler compiles {% content_for "name" %}…{% endcontent_for %},
+// which captures its body into the variable content_for_name.
+func contentForTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
+	varname, err := contentForVariable(node.Args)
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer, ctx render.Context) error {
+		s, err := ctx.InnerString()
+		if err != nil {
+			return err
+		}
+		ctx.Set(varname, s)
+		return nil
+	}, nil
+}
+
+// yieldTag compiles {% yield %} and {% yield "name" %}, which write the
+// content of the layout body or of the named content_for block.
+func yieldTag(source string) (func(io.Writer, render.Context) error, error) {
+	varname := "content_for_layout"
+	if strings.TrimSpace(source) != "" {
+		name, err := contentForVariable(source)
+		if err != nil {
+			return nil, err
+		}
+		varname = name
+	}
+	return func(w io.Writer, ctx render.Context) error {
+		if s, ok := ctx.Get(varname).(string); ok {
+			_, err := io.WriteString(w, s)
+			return err
+		}
+		return nil
+	}, nil
+}
+
+var contentNameRe = regexp.MustCompile(`^\w+$`)
+
+// contentForVariable returns the variable that holds the content for a name,
+// which may be quoted.
+func contentForVariable(source string) (string, error) {
+	name := strings.Trim(strings.TrimSpace(source), `"'`)
+	if !contentNameRe.MatchString(name) {
+		return "", fmt.Errorf("syntax error: invalid content name %q", source)
+	}
+	return "content_for_" + name, nil
+}
@@ -0,0 +1,48 @@
+package tags
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/osteele/liquid/parser"
+	"github.com/osteele/liquid/render"
+	"github.com/stretchr/testify/require"
+)
+
+var layoutTagTests = []struct{ in, expected string }{
+	{`{% layout "layout_theme.html" %}body{% endlayout %}`, "<main>body</main>"},
+	{`{% layout "layout_theme.html" %}{{ var }}{% endlayout %}`, "<main>value</main>"},
+	{`before{% layout "layout_theme.html" %}body{% endlayout %}after`, "before<main>body</main>\nafter"},
+	{`{% layout "layout_sidebar.html" %}{% content_for "sidebar" %}nav{% endcontent_for %}body{% endlayout %}`,
+		"<aside>nav</aside><main>body</main><p>body</p>"},
+	{`{% layout "layout_sidebar.html" %}body{% endlayout %}`, "<aside></aside><main>body</main><p>body</p>"},
+	{`{% content_for sidebar %}nav{% endcontent_for %}{% yield "sidebar" %}`, "nav"},
+	{`{% content_for sidebar %}nav{% endcontent_for %}{{ content_for_sidebar }}`, "nav"},
+}
+
+func TestLayoutTag(t *testing.T) {
+	config := render.NewConfig()
+	loc := parser.SourceLoc{Pathname: "testdata/layout_source.html", LineNo: 1}
+	AddStandardTags(config)
+
+	for _, test := range layoutTagTests {
+		root, err := config.Compile(test.in, loc)
+		require.NoErrorf(t, err, test.in)
+		buf := new(bytes.Buffer)
+		err = render.Render(root, buf, includeTestBindings, config)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, strings.TrimSpace(buf.String()), test.in)
+	}
+
+	// errors
+	_, err := config.Compile(`{% layout syntax error %}{% endlayout %}`, loc)
+	require.Error(t, err)
+	_, err = config.Compile(`{% content_for "a b" %}{% endcontent_for %}`, loc)
+	require.Error(t, err)
+	root, err := config.Compile(`{% layout 10 %}{% endlayout %}`, loc)
+	require.NoError(t, err)
+	err = render.Render(root, new(bytes.Buffer), includeTestBindings, config)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "requires a string")
+}
@@ -0,0 +1,161 @@
+package tags
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/osteele/liquid/expressions"
+	"github.com/osteele/liquid/render"
+	"github.com/osteele/liquid/values"
+)
+
+// currentPageVarName is the variable that {% paginate %} reads the page number
+// from.
+const currentPageVarName = "current_page"
+
+// paginateWindow is the number of pages on each side of the current page that
+// paginate.parts links to. Pages further away, except the first and last, are
+// replaced by an ellipsis.
+const paginateWindow = 2
+
+var paginateArgsRe = regexp.MustCompile(`^\s*(\w+(?:\.\w+)*)\s+by\s+(.+?)\s*$`)
+
+// paginateTagCompiler compiles {% paginate collection by n %}…{% endpaginate %}.
+//
+// Within the body, the collection holds only the items of the current page,
+// which is read from the current_page variable, and paginate holds the
+// pagination state: current_page, current_offset, items, page_size, pages,
+// previous, next, and parts, as in Shopify. The collection must be a variable,
+// or a property of a map.
+func paginateTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
+	m := paginateArgsRe.FindStringSubmatch(node.Args)
+	if m == nil {
+		return nil, fmt.Errorf("syntax error in paginate arguments %q", node.Args)
+	}
+	path := strings.Split(m[1], ".")
+	collectionExpr, err := expressions.Parse(m[1])
+	if err != nil {
+		return nil, err
+	}
+	sizeExpr, err := expressions.Parse(m[2])
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer, ctx render.Context) error {
+		value, err := ctx.Evaluate(collectionExpr)
+		if err != nil {
+			return err
+		}
+		size, err := paginateInt(ctx, sizeExpr)
+		if err != nil {
+			return err
+		}
+		if size < 1 {
+			return ctx.Errorf("paginate page size must be positive; got %d", size)
+		}
+		page := 1
+		if v := ctx.Get(currentPageVarName); v != nil {
+			if n, err := values.Convert(v, reflect.TypeOf(0)); err == nil && n.(int) > 1 {
+				page = n.(int)
+			}
+		}
+		items := []interface{}{}
+		count := 0
+		if iter := makeIterator(value); iter != nil {
+			count = iter.Len()
+			for i := (page - 1) * size; i < count && i < page*size; i++ {
+				items = append(items, iter.Index(i))
+			}
+		}
+		top, err := replacePath(ctx.Get(path[0]), path[1:], items)
+		if err != nil {
+			return ctx.Errorf("paginate can't replace %s: %s", m[1], err)
+		}
+		defer func(prev, prevPaginate interface{}) {
+			ctx.Set(path[0], prev)
+			ctx.Set("paginate", prevPaginate)
+		}(ctx.Get(path[0]), ctx.Get("paginate"))
+		ctx.Set(path[0], top)
+		ctx.Set("paginate", paginateState(page, size, count))
+		return ctx.RenderChildren(w)
+	}, nil
+}
+
+func paginateInt(ctx render.Context, expr expressions.Expression) (int, error) {
+	value, err := ctx.Evaluate(expr)
+	if err != nil {
+		return 0, err
+	}
+	n, err := values.Convert(value, reflect.TypeOf(0))
+	if err != nil {
+		return 0, ctx.Errorf("paginate page size must be an integer; got %v", value)
+	}
+	return n.(int), nil
+}
+
+// replacePath returns a copy of value in which the property at path is set to
+// item. Each value along the path must be a map[string]interface{}.
+func replacePath(value interface{}, path []string, item interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return item, nil
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%T is not a map", value)
+	}
+	v, err := replacePath(m[path[0]], path[1:], item)
+	if err != nil {
+		return nil, err
+	}
+	clone := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	clone[path[0]] = v
+	return clone, nil
+}
+
+// paginateState returns the value of the paginate variable.
+func paginateState(page, size, count int) map[string]interface{} {
+	pages := (count + size - 1) / size
+	link := func(title interface{}, n int) map[string]interface{} {
+		return map[string]interface{}{"title": title, "url": fmt.Sprintf("?page=%d", n), "is_link": true}
+	}
+	var parts []interface{}
+	gap := false
+	for n := 1; n <= pages; n++ {
+		switch {
+		case n == page:
+			parts = append(parts, map[string]interface{}{"title": n, "is_link": false})
+		case n == 1 || n == pages || (n >= page-paginateWindow && n <= page+paginateWindow):
+			parts = append(parts, link(n, n))
+		default:
+			if !gap {
+				parts = append(parts, map[string]interface{}{"title": "&hellip;", "is_link": false})
+			}
+			gap = true
+			continue
+		}
+		gap = false
+	}
+	state := map[string]interface{}{
+		"current_offset": (page - 1) * size,
+		"current_page":   page,
+		"items":          count,
+		"page_size":      size,
+		"pages":          pages,
+		"parts":          parts,
+		"previous":       nil,
+		"next":           nil,
+	}
+	if page > 1 {
+		state["previous"] = link("&laquo; Previous", page-1)
+	}
+	if page < pages {
+		state["next"] = link("Next &raquo;", page+1)
+	}
+	return state
+}
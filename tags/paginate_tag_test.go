@@ -0,0 +1,97 @@
+package tags
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/osteele/liquid/parser"
+	"github.com/osteele/liquid/render"
+	"github.com/stretchr/testify/require"
+)
+
+var paginateTagErrorTests = []struct{ in, expected string }{
+	{`{% paginate products %}{% endpaginate %}`, "syntax error"},
+	{`{% paginate products by %}{% endpaginate %}`, "syntax error"},
+	{`{% paginate products by 0 %}{% endpaginate %}`, "must be positive"},
+	{`{% paginate products by "x" %}{% endpaginate %}`, "must be an integer"},
+	{`{% paginate products.first by 2 %}{% endpaginate %}`, "can't replace products.first"},
+}
+
+const paginatePartsTemplate = `{% for part in paginate.parts %}{{ part.title }}{% if part.is_link %}*{% endif %},{% endfor %}`
+
+var paginateTagTests = []struct {
+	in       string
+	page     interface{}
+	expected string
+}{
+	{`{% paginate collection.products by 10 %}{% for p in collection.products %}{{ p }},{% endfor %}{% endpaginate %}`, 2,
+		"11,12,13,14,15,16,17,18,19,20,"},
+	{`{% paginate collection.products by 10 %}{{ paginate.current_page }} {{ paginate.current_offset }} {{ paginate.items }} {{ paginate.page_size }} {{ paginate.pages }}{% endpaginate %}`, 2,
+		"2 10 25 10 3"},
+	{`{% paginate collection.products by 10 %}{{ paginate.previous.title }} {{ paginate.previous.url }} {{ paginate.next.url }}{% endpaginate %}`, 2,
+		"&laquo; Previous ?page=1 ?page=3"},
+	{`{% paginate collection.products by 10 %}` + paginatePartsTemplate + `{% endpaginate %}`, 2, "1*,2,3*,"},
+	{`{% paginate collection.products by 2 %}` + paginatePartsTemplate + `{% endpaginate %}`, 7,
+		"1*,&hellip;,5*,6*,7,8*,9*,&hellip;,13*,"},
+	{`{% paginate collection.products by 10 %}{{ collection.products.size }}{% endpaginate %}{{ collection.products.size }}`, 3, "525"},
+	{`{% paginate collection.products by 10 %}{{ collection.title }}{% endpaginate %}`, 2, "Shirts"},
+	{`{% paginate products by 4 %}{{ products.size }}{% if paginate.previous %}prev{% endif %}{% endpaginate %}`, nil, "4"},
+	{`{% paginate products by 4 %}{{ products.first }}-{{ products.last }}{% endpaginate %}{{ products.size }}`, "3", "9-1225"},
+	{`{% paginate products by 10 %}{{ products.size }} {{ paginate.next }}{% endpaginate %}`, 4, "0 "},
+}
+
+func TestPaginateTag(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+	products := make([]int, 25)
+	for i := range products {
+		products[i] = i + 1
+	}
+	for _, test := range paginateTagTests {
+		root, err := config.Compile(test.in, parser.SourceLoc{})
+		require.NoErrorf(t, err, test.in)
+		buf := new(bytes.Buffer)
+		bindings := map[string]interface{}{
+			"collection": map[string]interface{}{"title": "Shirts", "products": products},
+			"products":   products,
+		}
+		if test.page != nil {
+			bindings["current_page"] = test.page
+		}
+		err = render.Render(root, buf, bindings, config)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, buf.String(), test.in)
+	}
+}
+
+func TestPaginateTag_lazyBindings(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+	root, err := config.Compile(`{% paginate collection.products by 2 %}{{ paginate.current_page }}:{% for p in collection.products %}{{ p }},{% endfor %}{% endpaginate %}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	bindings := map[string]interface{}{
+		"collection": func() interface{} {
+			return map[string]interface{}{"products": []int{1, 2, 3, 4, 5}}
+		},
+		"current_page": func() interface{} { return 2 },
+	}
+	err = render.Render(root, buf, bindings, config)
+	require.NoError(t, err)
+	require.Equal(t, "2:3,4,", buf.String())
+}
+
+func TestPaginateTag_errors(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+	bindings := map[string]interface{}{"products": []int{1, 2, 3}}
+	for _, test := range paginateTagErrorTests {
+		root, err := config.Compile(test.in, parser.SourceLoc{})
+		if err == nil {
+			err = render.Render(root, ioutil.Discard, bindings, config)
+		}
+		require.Errorf(t, err, test.in)
+		require.Containsf(t, err.Error(), test.expected, test.in)
+	}
+}
@@ -0,0 +1,105 @@
+package tags
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/osteele/liquid/render"
+)
+
+// schemaTagCompiler compiles {% schema %}…{% endschema %}. The body is the
+// JSON settings of a Shopify section. It's checked when the template is
+// compiled, and made available through Schema; the block writes nothing.
+func schemaTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
+	if _, err := parseSchema(&node); err != nil {
+		return nil, err
+	}
+	return func(io.Writer, render.Context) error { return nil }, nil
+}
+
+// Schema returns the parsed JSON body of the first {% schema %} block of a
+// compiled template, or nil if it doesn't have one.
+func Schema(root render.Node) map[string]interface{} {
+	n := findBlock(root, "schema")
+	if n == nil {
+		return nil
+	}
+	schema, _ := parseSchema(n)
+	return schema
+}
+
+// findBlock returns the first block with the given name, in source order.
+func findBlock(n render.Node, name string) *render.BlockNode {
+	switch n := n.(type) {
+	case *render.SeqNode:
+		for _, child := range n.Children {
+			if b := findBlock(child, name); b != nil {
+				return b
+			}
+		}
+	case *render.BlockNode:
+		if n.Name == name {
+			return n
+		}
+		for _, child := range n.Body {
+			if b := findBlock(child, name); b != nil {
+				return b
+			}
+		}
+		for _, clause := range n.Clauses {
+			if b := findBlock(clause, name); b != nil {
+				return b
+			}
+		}
+	}
+	return nil
+}
+
+// parseSchema parses the body of a schema block, which must be a JSON object.
+// Syntax errors report the line and column, within the template, at which
+// they occur.
+func parseSchema(n *render.BlockNode) (map[string]interface{}, error) {
+	text, err := blockText(n)
+	if err != nil {
+		return nil, err
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &schema); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			line, col := schemaPosition(n, text, syntaxErr.Offset)
+			return nil, fmt.Errorf("invalid JSON in schema at line %d, column %d: %s", line, col, syntaxErr)
+		case errors.As(err, &typeErr):
+			return nil, fmt.Errorf("schema must be a JSON object; got %s", typeErr.Value)
+		default:
+			return nil, fmt.Errorf("invalid JSON in schema: %s", err)
+		}
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("schema must be a JSON object")
+	}
+	return schema, nil
+}
+
+// schemaPosition returns the line and column of an offset into the body of a
+// schema block. Lines are numbered from the template's first line, as in
+// other errors. Columns on the line of the {% schema %} tag are counted from
+// the end of the tag.
+func schemaPosition(n *render.BlockNode, text string, offset int64) (line, col int) {
+	// a SyntaxError's offset is just past the byte that caused it
+	if offset > 0 {
+		offset--
+	}
+	if offset > int64(len(text)) {
+		offset = int64(len(text))
+	}
+	before := text[:offset]
+	line = n.SourceLoc.LineNo + strings.Count(n.Source+before, "\n")
+	col = len(before) - strings.LastIndex(before, "\n")
+	return line, col
+}
@@ -0,0 +1,62 @@
+package tags
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/osteele/liquid/parser"
+	"github.com/osteele/liquid/render"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaTag(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+
+	root, err := config.Compile(`<h1>{{ x }}</h1>
+{% schema %}
+{
+  "name": "Header",
+  "settings": [{"type": "text", "id": "title", "default": "Welcome"}]
+}
+{% endschema %}`, parser.SourceLoc{LineNo: 1})
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	err = render.Render(root, buf, tagTestBindings, config)
+	require.NoError(t, err)
+	require.Equal(t, "<h1>123</h1>\n", buf.String())
+
+	schema := Schema(root)
+	require.Equal(t, "Header", schema["name"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"type": "text", "id": "title", "default": "Welcome"},
+	}, schema["settings"])
+
+	// the body isn't parsed as Liquid
+	root, err = config.Compile(`{% schema %}{"name": "{{ name }}", "tag": "{% if %}"}{% endschema %}`, parser.SourceLoc{LineNo: 1})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"name": "{{ name }}", "tag": "{% if %}"}, Schema(root))
+
+	root, err = config.Compile(`no schema`, parser.SourceLoc{LineNo: 1})
+	require.NoError(t, err)
+	require.Nil(t, Schema(root))
+}
+
+func TestSchemaTag_errors(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+
+	tests := []struct{ in, expected string }{
+		{"a\n{% schema %}\n{\n  \"name\": \"Header\",\n  \"settings\": [}\n{% endschema %}", "invalid JSON in schema at line 5, column 16"},
+		{`{% schema %}{"name" "Header"}{% endschema %}`, "invalid JSON in schema at line 1, column 9"},
+		{`{% schema %}{"name": "Header"{% endschema %}`, "invalid JSON in schema"},
+		{`{% schema %}["Header"]{% endschema %}`, "schema must be a JSON object"},
+		{`{% schema %}null{% endschema %}`, "schema must be a JSON object"},
+		{`{% schema "a" %}{}{% endschema %}`, "doesn't take arguments"},
+	}
+	for _, test := range tests {
+		_, err := config.Compile(test.in, parser.SourceLoc{LineNo: 1})
+		require.Errorf(t, err, test.in)
+		require.Contains(t, err.Error(), test.expected, test.in)
+	}
+}
@@ -0,0 +1,84 @@
+package tags
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/osteele/liquid/expressions"
+	"github.com/osteele/liquid/render"
+)
+
+// sectionTag compiles {% section "name" %}, which renders a Shopify theme
+// section. The section's file and settings come from Config.Sections; the
+// settings are bound to section.settings, and the section's name to
+// section.id. As in Shopify, the output is wrapped in a div.
+func sectionTag(source string) (func(io.Writer, render.Context) error, error) {
+	expr, err := expressions.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer, ctx render.Context) error {
+		name, err := sectionName(ctx, expr)
+		if err != nil {
+			return err
+		}
+		return renderSection(w, ctx, name)
+	}, nil
+}
+
+// sectionsTag compiles {% sections "group" %}, which renders each of the
+// sections that Config.Sections.Groups lists for the group.
+func sectionsTag(source string) (func(io.Writer, render.Context) error, error) {
+	expr, err := expressions.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer, ctx render.Context) error {
+		group, err := sectionName(ctx, expr)
+		if err != nil {
+			return err
+		}
+		names, ok := ctx.Config().Sections.Groups[group]
+		if !ok {
+			return ctx.Errorf("undefined section group %q", group)
+		}
+		for _, name := range names {
+			if err := renderSection(w, ctx, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+func sectionName(ctx render.Context, expr expressions.Expression) (string, error) {
+	value, err := ctx.Evaluate(expr)
+	if err != nil {
+		return "", err
+	}
+	name, ok := value.(string)
+	if !ok {
+		return "", ctx.Errorf("%s requires a string argument; got %v", ctx.TagName(), value)
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", ctx.Errorf("invalid section name %q", name)
+	}
+	return name, nil
+}
+
+func renderSection(w io.Writer, ctx render.Context, name string) error {
+	cfg := ctx.Config()
+	settings := cfg.Sections.Settings[name]
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	s, err := ctx.RenderFile(cfg.SectionFilename(name), map[string]interface{}{
+		"section": map[string]interface{}{"id": name, "settings": settings},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, `<div id="shopify-section-%s" class="shopify-section">%s</div>`, name, s)
+	return err
+}
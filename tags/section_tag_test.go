@@ -0,0 +1,63 @@
+package tags
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/osteele/liquid/parser"
+	"github.com/osteele/liquid/render"
+	"github.com/stretchr/testify/require"
+)
+
+var sectionTagTests = []struct{ in, expected string }{
+	{`{% section "header" %}`, `<div id="shopify-section-header" class="shopify-section"><h1>Welcome</h1>` + "\n</div>"},
+	{`{% section 'footer' %}`, `<div id="shopify-section-footer" class="shopify-section">footer: ` + "\n</div>"},
+	{`{% assign name = "header" %}{% section name %}`, `<div id="shopify-section-header" class="shopify-section"><h1>Welcome</h1>` + "\n</div>"},
+	{`{% sections "main" %}`, `<div id="shopify-section-header" class="shopify-section"><h1>Welcome</h1>` + "\n</div>" +
+		`<div id="shopify-section-footer" class="shopify-section">footer: ` + "\n</div>"},
+}
+
+var sectionTagErrorTests = []struct{ in, expected string }{
+	{`{% section "missing" %}`, "no such file"},
+	{`{% section "../include_target" %}`, "invalid section name"},
+	{`{% section 1 %}`, "requires a string"},
+	{`{% sections "missing" %}`, "undefined section group"},
+}
+
+func TestSectionTag(t *testing.T) {
+	config := render.NewConfig()
+	config.TemplateRoot = "testdata"
+	config.Sections.Settings = map[string]map[string]interface{}{
+		"header": {"title": "Welcome"},
+	}
+	config.Sections.Groups = map[string][]string{"main": {"header", "footer"}}
+	loc := parser.SourceLoc{Pathname: "testdata/index.liquid", LineNo: 1}
+	AddStandardTags(config)
+
+	for _, test := range sectionTagTests {
+		root, err := config.Compile(test.in, loc)
+		require.NoErrorf(t, err, test.in)
+		buf := new(bytes.Buffer)
+		err = render.Render(root, buf, includeTestBindings, config)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, buf.String(), test.in)
+	}
+	for _, test := range sectionTagErrorTests {
+		root, err := config.Compile(test.in, loc)
+		require.NoErrorf(t, err, test.in)
+		err = render.Render(root, ioutil.Discard, includeTestBindings, config)
+		require.Errorf(t, err, test.in)
+		require.Containsf(t, err.Error(), test.expected, test.in)
+	}
+
+	// Sections.Dir overrides the location of the section files
+	config.Sections.Dir = "testdata/sections"
+	config.TemplateRoot = ""
+	root, err := config.Compile(`{% section "header" %}`, loc)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	err = render.Render(root, buf, includeTestBindings, config)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "<h1>Welcome</h1>")
+}
@@ -2,7 +2,10 @@
 package tags
 
 import (
+	"fmt"
 	"io"
+	"reflect"
+	"regexp"
 
 	"github.com/osteele/liquid/expressions"
 	"github.com/osteele/liquid/render"
@@ -12,6 +15,9 @@ import (
 func AddStandardTags(c render.Config) {
 	c.AddTag("assign", assignTag)
 	c.AddTag("include", includeTag)
+	c.AddTag("section", sectionTag)
+	c.AddTag("sections", sectionsTag)
+	c.AddTag("yield", yieldTag)
 
 	// blocks
 	// The parser only recognize the comment and raw tags if they've been defined,
@@ -22,11 +28,20 @@ func AddStandardTags(c render.Config) {
 	c.AddBlock("capture").Compiler(captureTagCompiler)
 	c.AddBlock("case").Clause("when").Clause("else").Compiler(caseTagCompiler)
 	c.AddBlock("comment")
+	c.AddBlock("content_for").Compiler(contentForTagCompiler)
 	c.AddBlock("for").Compiler(loopTagCompiler)
+	c.AddBlock("form").Compiler(formTagCompiler)
 	c.AddBlock("if").Clause("else").Clause("elsif").Compiler(ifTagCompiler(true))
+	c.AddBlock("ifchanged").Compiler(ifchangedTagCompiler)
+	c.AddOpaqueBlock("javascript").Compiler(assetTagCompiler)
+	c.AddBlock("layout").Compiler(layoutTagCompiler)
+	c.AddBlock("paginate").Compiler(paginateTagCompiler)
 	c.AddBlock("raw")
+	c.AddOpaqueBlock("schema").Compiler(schemaTagCompiler)
+	c.AddOpaqueBlock("stylesheet").Compiler(assetTagCompiler)
 	c.AddBlock("tablerow").Compiler(loopTagCompiler)
 	c.AddBlock("unless").Clause("else").Compiler(ifTagCompiler(false))
+	c.AddBlock("with").Compiler(withTagCompiler)
 }
 
 func assignTag(source string) (func(io.Writer, render.Context) error, error) {
@@ -39,15 +54,62 @@ func assignTag(source string) (func(io.Writer, render.Context) error, error) {
 		if err != nil {
 			return err
 		}
-		_ = value
+		if vars := stmt.Assignment.Variables; len(vars) > 1 {
+			// {% assign a, b = pair %} binds the elements positionally. Names
+			// without a corresponding element are bound to nil.
+			elems := destructure(value)
+			for i, name := range vars {
+				var item interface{}
+				if i < len(elems) {
+					item = elems[i]
+				}
+				ctx.Set(name, item)
+			}
+			return nil
+		}
 		ctx.Set(stmt.Assignment.Variable, value)
 		return nil
 	}, nil
 }
 
+// destructure returns the elements of an array or slice. Any other value is
+// treated as a single element.
+func destructure(value interface{}) []interface{} {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Slice:
+		elems := make([]interface{}, rv.Len())
+		for i := range elems {
+			elems[i] = rv.Index(i).Interface()
+		}
+		return elems
+	case reflect.Invalid:
+		return nil
+	default:
+		return []interface{}{value}
+	}
+}
+
+var captureArgsRe = regexp.MustCompile(`^\s*(?:(\w[\w-]*)|'(\w[\w-]*)'|"(\w[\w-]*)")\s*$`)
+
+// captureVariable returns the name of the variable that {% capture args %}
+// sets.
+func captureVariable(args string) (string, error) {
+	m := captureArgsRe.FindStringSubmatch(args)
+	if m == nil {
+		return "", fmt.Errorf("syntax error in capture arguments %q", args)
+	}
+	return m[1] + m[2] + m[3], nil
+}
+
+// captureTagCompiler compiles {% capture name %}…{% endcapture %}. The name
+// can be quoted. As with assign, the variable is set in the enclosing scope,
+// so it's visible after any loop or block that contains the tag.
 func captureTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
-	// TODO verify syntax
-	varname := node.Args
+	varname, err := captureVariable(node.Args)
+	if err != nil {
+		return nil, err
+	}
 	return func(w io.Writer, ctx render.Context) error {
 		s, err := ctx.InnerString()
 		if err != nil {
@@ -57,3 +119,22 @@ func captureTagCompiler(node render.BlockNode) (func(io.Writer, render.Context)
 		return nil
 	}, nil
 }
+
+// withTagCompiler compiles {% with name = expr %}…{% endwith %}. The variable
+// is bound only within the body; its previous value is restored afterwards.
+func withTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
+	stmt, err := expressions.ParseStatement(expressions.AssignStatementSelector, node.Args)
+	if err != nil {
+		return nil, err
+	}
+	name := stmt.Assignment.Variable
+	return func(w io.Writer, ctx render.Context) error {
+		value, err := ctx.Evaluate(stmt.Assignment.ValueFn)
+		if err != nil {
+			return err
+		}
+		defer func(prev interface{}) { ctx.Set(name, prev) }(ctx.Get(name))
+		ctx.Set(name, value)
+		return ctx.RenderChildren(w)
+	}, nil
+}
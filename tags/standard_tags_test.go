@@ -14,6 +14,10 @@ import (
 var parseErrorTests = []struct{ in, expected string }{
 	{"{% undefined_tag %}", "undefined tag"},
 	{"{% assign v x y z %}", "syntax error"},
+	{"{% assign a, = b %}", "syntax error"},
+	{"{% with v x y z %}{% endwith %}", "syntax error"},
+	{"{% capture %}{% endcapture %}", "syntax error"},
+	{"{% capture a b %}{% endcapture %}", "syntax error"},
 	{"{% if syntax error %}", `unterminated "if" block`},
 	// TODO once expression parsing is moved to template parse stage
 	// {"{% if syntax error %}{% endif %}", "syntax error"},
@@ -25,7 +29,23 @@ var tagTests = []struct{ in, expected string }{
 	{`{% assign av = 1 %}{{ av }}`, "1"},
 	{`{% assign av = obj.a %}{{ av }}`, "1"},
 	{`{% assign av = (1..5) %}{{ av }}`, "{1 5}"},
+	{`{% assign a, b = pair %}{{ a }},{{ b }}`, "first,second"},
+	{`{% assign a, b = single %}{{ a }},{{ b }}.`, "first,."},
+	{`{% assign a, b, c = animals %}{{ a }},{{ b }},{{ c }}`, "zebra,octopus,giraffe"},
+	{`{% assign a, b = x %}{{ a }},{{ b }}.`, "123,."},
+	{`{% assign b = 1 %}{% assign a, b = missing %}{{ a }},{{ b }}.`, ",."},
 	{`{% capture x %}captured{% endcapture %}{{ x }}`, "captured"},
+	{`{% capture "x" %}captured{% endcapture %}{{ x }}`, "captured"},
+	{`{% capture x %}{% for a in pair %}{{ a }}{% endfor %}{% endcapture %}{{ x }}`, "firstsecond"},
+	{`{% for a in pair %}{% capture x %}{{ a }}{% endcapture %}{% endfor %}{{ x }}`, "second"},
+	{`{% for a in pair %}{% if true %}{% capture x %}{{ a }}{% endcapture %}{% endif %}{% endfor %}{{ x }}`, "second"},
+	{`{% assign x = "" %}{% for a in pair %}{% capture x %}{{ x }}{{ a }}{% endcapture %}{% endfor %}{{ x }}`, "firstsecond"},
+	{`{% capture x %}out{% endcapture %}{% for a in pair %}{{ x }},{% endfor %}{{ x }}`, "out,out,out"},
+	{`{% capture a %}c{% endcapture %}{% for a in pair %}{{ a }},{% endfor %}{{ a }}`, "first,second,c"},
+	{`{% with user = page.title %}{{ user }}{% endwith %}`, "Introduction"},
+	{`{% with user = page.title %}{% endwith %}[{{ user }}]`, "[]"},
+	{`{% with x = obj.a %}{{ x }}{% endwith %}{{ x }}`, "1123"},
+	{`{% with a = 1 %}{% with a = 2 %}{{ a }}{% endwith %}{{ a }}{% endwith %}`, "21"},
 
 	// TODO research whether Liquid requires matching interior tags
 	{`{% comment %}{{ a }}{% undefined_tag %}{% endcomment %}`, ""},
@@ -41,7 +61,10 @@ var tagErrorTests = []struct{ in, expected string }{
 
 // this is also used in the other test files
 var tagTestBindings = map[string]interface{}{
-	"x": 123,
+	"x":           123,
+	"pair":        []string{"first", "second"},
+	"single":      []string{"first"},
+	"empty_array": []string{},
 	"obj": map[string]interface{}{
 		"a": 1,
 	},
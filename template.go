@@ -2,27 +2,31 @@ package liquid
 
 import (
 	"bytes"
+	"context"
 	"io"
 
 	"github.com/osteele/liquid/parser"
 	"github.com/osteele/liquid/render"
+	"github.com/osteele/liquid/tags"
 )
 
 // A Template is a compiled Liquid template. It knows how to evaluate itself within a variable binding environment, to create a rendered byte slice.
 //
 // Use Engine.ParseTemplate to create a template.
 type Template struct {
-	root render.Node
-	cfg  *render.Config
+	root   render.Node
+	cfg    *render.Config
+	source string
+	loc    parser.SourceLoc
 }
 
 func newTemplate(cfg *render.Config, source []byte, path string, line int) (*Template, SourceError) {
 	loc := parser.SourceLoc{Pathname: path, LineNo: line}
 	root, err := cfg.Compile(string(source), loc)
 	if err != nil {
-		return nil, err
+		return nil, &ParseError{err}
 	}
-	return &Template{root, cfg}, nil
+	return &Template{root, cfg, string(source), loc}, nil
 }
 
 // GetRoot returns the root node of the abstract syntax tree (AST) representing
@@ -31,12 +35,69 @@ func (t *Template) GetRoot() render.Node {
 	return t.root
 }
 
+// Source returns the text that the template was parsed from. The Source fields
+// of the tokens that the template is parsed from concatenate to the same text,
+// so a transform can rewrite some tokens and reproduce the rest verbatim.
+func (t *Template) Source() string {
+	return t.source
+}
+
+// Variables returns the names of the top-level variables that the template
+// refers to, in order of first reference. Names that the template binds
+// itself, such as loop variables and the targets of assign and capture, are
+// omitted. This can be used to check that a set of bindings supplies
+// everything the template needs.
+func (t *Template) Variables() []string {
+	return tags.Variables(t.root)
+}
+
+// Assets returns the contents of the template's {% stylesheet %} and
+// {% javascript %} blocks. These blocks write nothing where they appear; a
+// host collects their contents from here, for example to emit them in the
+// page head. The contents don't depend on the bindings, so Assets can be
+// called before or after Render.
+func (t *Template) Assets() Assets {
+	return tags.CollectAssets(t.root)
+}
+
+// Schema returns the parsed JSON body of the template's {% schema %} block, as
+// in a Shopify section file, or nil if the template doesn't have one. The
+// block writes nothing; its JSON is checked when the template is parsed.
+func (t *Template) Schema() map[string]interface{} {
+	return tags.Schema(t.root)
+}
+
+// Validate checks the template against a configuration, such as that of
+// another engine (see Engine.Config), without rendering it. It reports the
+// tags that cfg doesn't define, and blocks that aren't terminated, as
+// ParseTemplate would; and references to filters that cfg doesn't define,
+// which would otherwise only be detected when the template is rendered.
+func (t *Template) Validate(cfg Config) []error {
+	root, err := cfg.Compile(t.source, t.loc)
+	if err != nil {
+		return []error{&ParseError{err}}
+	}
+	return tags.Validate(root, cfg)
+}
+
 // Render executes the template with the specified variable bindings.
 func (t *Template) Render(vars Bindings) ([]byte, SourceError) {
 	buf := new(bytes.Buffer)
 	err := render.Render(t.root, buf, vars, *t.cfg)
 	if err != nil {
-		return nil, err
+		return nil, &RenderError{err}
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderCtx is like Render, but stops with an error that wraps ctx.Err() if
+// ctx is canceled or its deadline passes during rendering. Use it to bound the
+// time that a template can take to render.
+func (t *Template) RenderCtx(ctx context.Context, vars Bindings) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := render.RenderCtx(ctx, t.root, buf, vars, *t.cfg)
+	if err != nil {
+		return nil, &RenderError{err}
 	}
 	return buf.Bytes(), nil
 }
@@ -45,7 +106,7 @@ func (t *Template) Render(vars Bindings) ([]byte, SourceError) {
 func (t *Template) FRender(w io.Writer, vars Bindings) SourceError {
 	err := render.Render(t.root, w, vars, *t.cfg)
 	if err != nil {
-		return err
+		return &RenderError{err}
 	}
 	return nil
 }
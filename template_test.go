@@ -1,9 +1,13 @@
 package liquid
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/osteele/liquid/render"
 	"github.com/stretchr/testify/require"
@@ -24,6 +28,129 @@ func TestTemplate_RenderString(t *testing.T) {
 	require.Equal(t, "Hello world", out)
 }
 
+func TestTemplate_Source(t *testing.T) {
+	src := "  {{- x }}\t{%if x%}\r\n{%   else -%}\n{% endif %}{% # note %}\n{% liquid\n  # comment\n  echo x\n%} "
+	engine := NewEngine()
+	tpl, err := engine.ParseTemplate([]byte(src))
+	require.NoError(t, err)
+	require.Equal(t, src, tpl.Source())
+}
+
+func TestTemplate_RenderCtx(t *testing.T) {
+	engine := NewEngine()
+	engine.RegisterFilter("slow", func(n int) int {
+		time.Sleep(time.Millisecond)
+		return n
+	})
+	tpl, perr := engine.ParseString(`{% for i in (1..3) %}{{ i | slow }}{% endfor %}`)
+	require.NoError(t, perr)
+	out, err := tpl.RenderCtx(context.Background(), emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "123", string(out))
+
+	// an already-canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = tpl.RenderCtx(ctx, emptyBindings)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.Canceled))
+
+	// a deadline that passes during the loop
+	tpl, perr = engine.ParseString(`{% for i in (1..10000) %}{{ i | slow }}{% endfor %}`)
+	require.NoError(t, perr)
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = tpl.RenderCtx(ctx, emptyBindings)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestTemplate_Variables(t *testing.T) {
+	engine := NewEngine()
+	tpl, err := engine.ParseTemplate([]byte(`
+		<h1>{{ page.title | default: site.title }}</h1>
+		{% if user.admin and show_tools %}{{ tools }}{% endif %}
+		{% for post in posts %}{{ post.title }} {{ forloop.index }}{% endfor %}
+		{% assign n = posts | size %}{{ n }} {{ page.title }}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"page", "site", "user", "show_tools", "tools", "posts"}, tpl.Variables())
+}
+
+func TestTemplate_Assets(t *testing.T) {
+	engine := NewEngine()
+	tpl, err := engine.ParseString(`<p>{{ x }}</p>
+{% stylesheet %}.a { color: red; }{% endstylesheet %}
+{% if false %}{% javascript %}console.log("b");{% endjavascript %}{% endif %}
+{% stylesheet %}.c { color: blue; }{% endstylesheet %}`)
+	require.NoError(t, err)
+	out, err := tpl.RenderString(map[string]interface{}{"x": 1})
+	require.NoError(t, err)
+	require.Equal(t, "<p>1</p>\n\n\n", out)
+	require.Equal(t, Assets{
+		Stylesheets: []string{".a { color: red; }", ".c { color: blue; }"},
+		Javascripts: []string{`console.log("b");`},
+	}, tpl.Assets())
+}
+
+func TestTemplate_Schema(t *testing.T) {
+	engine := NewEngine()
+	tpl, err := engine.ParseString(`{{ section.settings.title }}{% schema %}{"name": "Header", "limit": 1}{% endschema %}`)
+	require.NoError(t, err)
+	out, err := tpl.RenderString(emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "", out)
+	require.Equal(t, map[string]interface{}{"name": "Header", "limit": 1.0}, tpl.Schema())
+
+	_, err = engine.ParseString(`{% schema %}{"name": }{% endschema %}`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid JSON in schema")
+}
+
+func TestTemplate_Validate(t *testing.T) {
+	engine := NewEngine()
+	cfg := engine.Config()
+	tpl, err := engine.ParseTemplate([]byte(`{{ "a" | upcase }}{% if x | size %}{% endif %}`))
+	require.NoError(t, err)
+	require.Empty(t, tpl.Validate(cfg))
+
+	tpl, err = engine.ParseTemplate([]byte(`{{ "a" | upcase | no_such_filter }}`))
+	require.NoError(t, err)
+	errs := tpl.Validate(cfg)
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Error(), `undefined filter "no_such_filter"`)
+
+	// Templates are checked against the configuration that is passed in, not
+	// the one they were parsed with. These ones parse with a more permissive
+	// engine, in which if is a tag and widget is defined.
+	permissive := NewEngine()
+	permissive.RegisterTag("if", func(render.Context) (string, error) { return "", nil })
+	permissive.RegisterTag("widget", func(render.Context) (string, error) { return "", nil })
+	permissive.RegisterFilter("shout", strings.ToUpper)
+
+	tpl, err = permissive.ParseTemplateLocation([]byte("text\n{% widget %}"), "page.html", 1)
+	require.NoError(t, err)
+	require.Empty(t, tpl.Validate(permissive.Config()))
+	errs = tpl.Validate(cfg)
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Error(), "undefined tag")
+	require.Contains(t, errs[0].Error(), "(line 2)")
+
+	tpl, err = permissive.ParseTemplate([]byte(`{% if true %}{{ "a" | shout }}`))
+	require.NoError(t, err)
+	require.Empty(t, tpl.Validate(permissive.Config()))
+	errs = tpl.Validate(cfg)
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Error(), "unterminated")
+
+	tpl, err = permissive.ParseTemplate([]byte(`{{ "a" | shout }}`))
+	require.NoError(t, err)
+	errs = tpl.Validate(cfg)
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Error(), `undefined filter "shout"`)
+}
+
 func TestTemplate_SetSourcePath(t *testing.T) {
 	engine := NewEngine()
 	engine.RegisterTag("sourcepath", func(c render.Context) (string, error) {
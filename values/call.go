@@ -8,7 +8,8 @@ import (
 // Call applies a function to arguments, converting them as necessary.
 //
 // The conversion follows Liquid (Ruby?) semantics, which are more aggressive than
-// Go conversion.
+// Go conversion. Each argument is converted to its parameter's type by
+// Convert, which describes how strings convert to numbers.
 //
 // The function should return one or two values; the second value,
 // if present, should be an error.
@@ -28,6 +29,18 @@ func (e *CallParityError) Error() string {
 	return fmt.Sprintf("wrong number of arguments (given %d, expected %d)", e.NumArgs, e.NumParams)
 }
 
+// A CallArgumentError is a failure to convert an argument to the type of the
+// corresponding parameter.
+type CallArgumentError struct {
+	Index int // the index of the argument
+	Err   error
+}
+
+func (e *CallArgumentError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the conversion error.
+func (e *CallArgumentError) Unwrap() error { return e.Err }
+
 func convertCallResults(results []reflect.Value) (interface{}, error) {
 	if len(results) > 1 && results[1].Interface() != nil {
 		switch e := results[1].Interface().(type) {
@@ -68,7 +81,11 @@ func convertCallArguments(fn reflect.Value, args []interface{}) (results []refle
 		case arg == nil:
 			results[i] = reflect.Zero(typ)
 		default:
-			results[i] = reflect.ValueOf(MustConvert(arg, typ))
+			value, err := Convert(arg, typ)
+			if err != nil {
+				return nil, &CallArgumentError{Index: i, Err: err}
+			}
+			results[i] = reflect.ValueOf(value)
 		}
 	}
 
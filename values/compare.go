@@ -2,6 +2,9 @@ package values
 
 import (
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var (
@@ -15,6 +18,15 @@ func Equal(a, b interface{}) bool { // nolint: gocyclo
 	if a == nil || b == nil {
 		return a == b
 	}
+	if a == Empty || b == Empty {
+		return a == b || isEmptyCollection(a) || isEmptyCollection(b)
+	}
+	if eq, ok := equalSameType(a, b); ok {
+		return eq
+	}
+	if ta, tb, ok := comparableTimes(a, b); ok {
+		return ta.Equal(tb)
+	}
 	ra, rb := reflect.ValueOf(a), reflect.ValueOf(b)
 	switch joinKind(ra.Kind(), rb.Kind()) {
 	case reflect.Array, reflect.Slice:
@@ -46,11 +58,24 @@ func Equal(a, b interface{}) bool { // nolint: gocyclo
 }
 
 // Less returns a bool indicating whether a < b.
+//
+// A number and a string are compared as numbers if the string is numeric, so
+// "2" > 1; otherwise neither is less than the other. (Equal doesn't convert
+// strings, so 2 == "2" is false, as in Shopify.)
 func Less(a, b interface{}) bool {
 	a, b = ToLiquid(a), ToLiquid(b)
 	if a == nil || b == nil {
 		return false
 	}
+	if less, ok := lessSameType(a, b); ok {
+		return less
+	}
+	if x, y, mixed, numeric := numberAndString(a, b); mixed {
+		return numeric && x < y
+	}
+	if ta, tb, ok := comparableTimes(a, b); ok {
+		return ta.Before(tb)
+	}
 	ra, rb := reflect.ValueOf(a), reflect.ValueOf(b)
 	switch joinKind(ra.Kind(), rb.Kind()) {
 	case reflect.Bool:
@@ -66,6 +91,104 @@ func Less(a, b interface{}) bool {
 	}
 }
 
+// LessOrEqual returns a bool indicating whether a <= b. It is Less(a, b) ||
+// Equal(a, b), except that a number and a numeric string that Less compares
+// as numbers are also equal if the numbers are.
+func LessOrEqual(a, b interface{}) bool {
+	a, b = ToLiquid(a), ToLiquid(b)
+	if x, y, mixed, numeric := numberAndString(a, b); mixed {
+		return numeric && x <= y
+	}
+	return Less(a, b) || Equal(a, b)
+}
+
+// numberAndString converts a and b to float64, if one is a number and the
+// other is a string. mixed is false if they aren't a number and a string;
+// numeric is false if the string isn't a number.
+func numberAndString(a, b interface{}) (x, y float64, mixed, numeric bool) {
+	ra, rb := reflect.ValueOf(a), reflect.ValueOf(b)
+	isNumber := func(k reflect.Kind) bool { return isIntKind(k) || isFloatKind(k) }
+	parse := func(s string) (float64, error) { return strconv.ParseFloat(strings.TrimSpace(s), 64) }
+	switch {
+	case isNumber(ra.Kind()) && rb.Kind() == reflect.String:
+		y, err := parse(rb.String())
+		return ra.Convert(float64Type).Float(), y, true, err == nil
+	case ra.Kind() == reflect.String && isNumber(rb.Kind()):
+		x, err := parse(ra.String())
+		return x, rb.Convert(float64Type).Float(), true, err == nil
+	}
+	return 0, 0, false, false
+}
+
+// equalSameType compares the common cases of two ints, floats, or strings
+// without reflection. It returns false for ok if the types are different.
+func equalSameType(a, b interface{}) (eq, ok bool) {
+	switch a := a.(type) {
+	case int:
+		if b, ok := b.(int); ok {
+			return a == b, true
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return a == b, true
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return a == b, true
+		}
+	}
+	return false, false
+}
+
+// lessSameType is the counterpart of equalSameType, for Less.
+func lessSameType(a, b interface{}) (less, ok bool) {
+	switch a := a.(type) {
+	case int:
+		if b, ok := b.(int); ok {
+			return a < b, true
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return a < b, true
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return a < b, true
+		}
+	}
+	return false, false
+}
+
+// comparableTimes converts a and b to times, if one is a time and the other is
+// either a time or a string that ParseDate can parse.
+func comparableTimes(a, b interface{}) (ta, tb time.Time, ok bool) {
+	ta, aok := a.(time.Time)
+	tb, bok := b.(time.Time)
+	var err error
+	switch {
+	case aok && bok:
+	case aok:
+		s, isString := b.(string)
+		if !isString {
+			return ta, tb, false
+		}
+		if tb, err = ParseDate(s); err != nil {
+			return ta, tb, false
+		}
+	case bok:
+		s, isString := a.(string)
+		if !isString {
+			return ta, tb, false
+		}
+		if ta, err = ParseDate(s); err != nil {
+			return ta, tb, false
+		}
+	default:
+		return ta, tb, false
+	}
+	return ta, tb, true
+}
+
 func joinKind(a, b reflect.Kind) reflect.Kind { // nolint: gocyclo
 	if a == b {
 		return a
@@ -3,6 +3,7 @@ package values
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -37,6 +38,14 @@ var eqTests = []struct {
 	{[]string{"a", "b"}, []string{"a", "c"}, false},
 	{[]interface{}{1.0, 2}, []interface{}{1, 2.0}, true},
 	{eqTestObj, eqTestObj, true},
+	{Empty, Empty, true},
+	{Empty, "", true},
+	{[]string{}, Empty, true},
+	{map[string]int{}, Empty, true},
+	{[]string{"a"}, Empty, false},
+	{nil, Empty, false},
+	{Empty, false, false},
+	{Empty, 0, false},
 }
 
 func TestEqual(t *testing.T) {
@@ -68,3 +77,27 @@ func TestEqual_ptr(t *testing.T) {
 	// require.True(t, Equal(pn, nil)) // TODO
 	// require.True(t, Equal(nil, pn)) // TODO
 }
+
+func TestCompare_times(t *testing.T) {
+	t1 := time.Date(2015, 7, 17, 15, 4, 5, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	require.True(t, Less(t1, t2))
+	require.False(t, Less(t2, t1))
+	require.False(t, Less(t1, t1))
+	require.True(t, Equal(t1, t1))
+	require.True(t, Equal(t1, t1.In(time.FixedZone("X", 3600))))
+	require.False(t, Equal(t1, t2))
+
+	// a time and a string that can be parsed as one
+	require.True(t, Less(t1, "2016-01-01"))
+	require.True(t, Less("2015-01-01", t1))
+	require.False(t, Less(t1, "2015-01-01"))
+	require.True(t, Less(t1, "now"))
+	require.True(t, Equal(t1, "2015-07-17T15:04:05Z"))
+
+	// anything else is incomparable
+	require.False(t, Less(t1, "not a date"))
+	require.False(t, Less("not a date", t1))
+	require.False(t, Equal(t1, "not a date"))
+	require.False(t, Less(t1, 1))
+}
@@ -3,8 +3,10 @@ package values
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	yaml "gopkg.in/yaml.v2"
@@ -40,11 +42,16 @@ func convertValueToInt(value interface{}, typ reflect.Type) (int64, error) {
 		}
 		return 0, nil
 	case string:
-		v, err := strconv.ParseInt(value, 10, 64)
-		if err != nil {
-			return 0, conversionError("", value, typ)
+		s := strings.TrimSpace(value)
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return v, nil
 		}
-		return v, nil
+		// "5.0" converts to 5, but "5.5" is an error
+		if f, err := strconv.ParseFloat(s, 64); err == nil && f == math.Trunc(f) &&
+			f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), nil
+		}
+		return 0, conversionError("", value, typ)
 	case json.Number:
 		v, err := strconv.ParseInt(value.String(), 10, 64)
 		if err != nil {
@@ -60,7 +67,7 @@ func convertValueToFloat(value interface{}, typ reflect.Type) (float64, error) {
 	switch value := value.(type) {
 	// case int is handled by rv.Convert(typ) in Convert function
 	case string:
-		v, err := strconv.ParseFloat(value, 64)
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
 		if err != nil {
 			return 0, conversionError("", value, typ)
 		}
@@ -78,6 +85,17 @@ func convertValueToFloat(value interface{}, typ reflect.Type) (float64, error) {
 // Convert value to the type. This is a more aggressive conversion, that will
 // recursively create new map and slice values as necessary. It doesn't
 // handle circular references.
+//
+// A value is converted to a numeric type by the first of these rules that
+// applies:
+//
+//  1. A number is converted as by Go, so 5.7 converts to the int 5.
+//  2. A string or json.Number is parsed, ignoring surrounding whitespace. For
+//     an integer type, it must be an integer, such as "5" or "5.0".
+//  3. A bool converts to 1 for true and 0 for false, for an integer type.
+//
+// Anything else is an error. Filter arguments are converted this way (see
+// Call), so "5" | plus: 2 is 7.
 func Convert(value interface{}, typ reflect.Type) (interface{}, error) { // nolint: gocyclo
 	value = ToLiquid(value)
 	rv := reflect.ValueOf(value)
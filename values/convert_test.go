@@ -69,6 +69,11 @@ var convertTests = []struct {
 	{"2.1", 2.1},
 	{"2.1", float32(2.1)},
 	{"2.1", float64(2.1)},
+	{" 2 ", 2},
+	{"2.0", 2},
+	{"1e2", 100},
+	{" 2.5\n", 2.5},
+	{true, 1},
 	{"string", "string"},
 	{[]interface{}{1, 2}, []interface{}{1, 2}},
 	{[]int{1, 2}, []int{1, 2}},
@@ -100,6 +105,9 @@ var convertErrorTests = []struct {
 	{"notanumber", int(0), []string{"can't convert string", "to type int"}},
 	{"notanumber", uint(0), []string{"can't convert string", "to type uint"}},
 	{"notanumber", float64(0), []string{"can't convert string", "to type float64"}},
+	{"2.5", int(0), []string{"can't convert string", "to type int"}},
+	{"NaN", int(0), []string{"can't convert string", "to type int"}},
+	{"1e30", int(0), []string{"can't convert string", "to type int"}},
 }
 
 func TestConvert(t *testing.T) {
@@ -25,6 +25,39 @@ var lessTests = []struct {
 	{"a", "b", true},
 	{"b", "a", false},
 	{[]string{"a"}, []string{"a"}, false},
+	// a number and a numeric string are compared as numbers
+	{"1", 2, true},
+	{2, "10", true},
+	{"2.5", 2, false},
+	{1.5, " 2 ", true},
+	{"abc", 1, false},
+	{1, "abc", false},
+}
+
+var lessOrEqualTests = []struct {
+	a, b     interface{}
+	expected bool
+}{
+	{1, 1, true},
+	{1, 2, true},
+	{2, 1, false},
+	{"a", "a", true},
+	{"2", 2, true},
+	{2, "2.0", true},
+	{"3", 2, false},
+	{"abc", 1, false},
+	{1, "abc", false},
+	{nil, nil, true},
+	{nil, 1, false},
+}
+
+func TestLessOrEqual(t *testing.T) {
+	for i, test := range lessOrEqualTests {
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			value := LessOrEqual(test.a, test.b)
+			require.Equalf(t, test.expected, value, "%#v <= %#v", test.a, test.b)
+		})
+	}
 }
 
 func TestLess(t *testing.T) {
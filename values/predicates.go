@@ -10,6 +10,9 @@ func IsEmpty(value interface{}) bool {
 	if value == nil {
 		return false
 	}
+	if value == Empty {
+		return true
+	}
 	r := reflect.ValueOf(value)
 	switch r.Kind() {
 	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
@@ -20,3 +23,24 @@ func IsEmpty(value interface{}) bool {
 		return false
 	}
 }
+
+// Empty is the value of the Liquid empty literal, as in {% if items == empty %}.
+// It is equal to empty strings, arrays, and maps, but not to nil or false.
+var Empty interface{} = emptyLiteral{}
+
+type emptyLiteral struct{}
+
+// String renders the empty literal as an empty string.
+func (emptyLiteral) String() string { return "" }
+
+// isEmptyCollection returns a bool indicating whether value is an empty
+// string, array, slice, or map.
+func isEmptyCollection(value interface{}) bool {
+	r := reflect.ValueOf(value)
+	switch r.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return r.Len() == 0
+	default:
+		return false
+	}
+}
@@ -13,4 +13,10 @@ func TestIsEmpty(t *testing.T) {
 	require.True(t, IsEmpty(map[string]interface{}{}))
 	require.False(t, IsEmpty([]string{""}))
 	require.False(t, IsEmpty(map[string]interface{}{"k": "v"}))
+	require.True(t, IsEmpty(""))
+	require.False(t, IsEmpty(" "))
+	require.False(t, IsEmpty(nil))
+	require.False(t, IsEmpty(0))
+	require.False(t, IsEmpty(0.0))
+	require.True(t, IsEmpty(Empty))
 }
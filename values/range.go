@@ -1,5 +1,7 @@
 package values
 
+import "reflect"
+
 // A Range is the range of integers from b to e inclusive.
 type Range struct {
 	b, e int
@@ -10,8 +12,14 @@ func NewRange(b, e int) Range {
 	return Range{b, e}
 }
 
-// Len is in the iteration interface
-func (r Range) Len() int { return r.e + 1 - r.b }
+// Len is in the iteration interface. It is zero if the range is empty,
+// because its end is less than its start.
+func (r Range) Len() int {
+	if r.e < r.b {
+		return 0
+	}
+	return r.e + 1 - r.b
+}
 
 // Index is in the iteration interface
 func (r Range) Index(i int) interface{} { return r.b + i }
@@ -24,3 +32,18 @@ func (r Range) AsArray() []interface{} {
 	}
 	return a
 }
+
+// Includes returns true if value is an integer within the range.
+func (r Range) Includes(value interface{}) bool {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := rv.Int()
+		return int64(r.b) <= n && n <= int64(r.e)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := rv.Uint()
+		return r.e >= 0 && n <= uint64(r.e) && (r.b < 0 || uint64(r.b) <= n)
+	default:
+		return false
+	}
+}
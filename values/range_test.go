@@ -0,0 +1,30 @@
+package values
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRange_Includes(t *testing.T) {
+	r := NewRange(1, 5)
+	require.True(t, r.Includes(1))
+	require.True(t, r.Includes(3))
+	require.True(t, r.Includes(int64(5)))
+	require.True(t, r.Includes(uint8(2)))
+	require.False(t, r.Includes(0))
+	require.False(t, r.Includes(6))
+	require.False(t, r.Includes(3.0))
+	require.False(t, r.Includes("3"))
+	require.False(t, r.Includes(nil))
+	require.True(t, NewRange(-2, 2).Includes(uint(0)))
+	require.False(t, NewRange(-2, -1).Includes(uint(0)))
+}
+
+func TestRange_Len(t *testing.T) {
+	require.Equal(t, 5, NewRange(1, 5).Len())
+	require.Equal(t, 1, NewRange(3, 3).Len())
+	require.Equal(t, 0, NewRange(5, 1).Len())
+	require.Equal(t, []interface{}{}, NewRange(5, 1).AsArray())
+	require.Equal(t, 7, NewRange(5, 9).Index(2))
+}
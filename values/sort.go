@@ -29,15 +29,51 @@ func (s genericSortable) Less(i, j int) bool {
 
 // SortByProperty sorts maps on their key indices.
 func SortByProperty(data []interface{}, key string, nilFirst bool) {
-	sort.Sort(sortableByProperty{data, key, nilFirst})
+	sort.Sort(newSortableByProperty(data, []string{key}, nilFirst))
+}
+
+// SortByProperties sorts maps on their key indices. Items that are equal on
+// the first key are ordered by the second, and so on. The sort is stable.
+func SortByProperties(data []interface{}, keys []string, nilFirst bool) {
+	sort.Stable(newSortableByProperty(data, keys, nilFirst))
 }
 
 type sortableByProperty struct {
-	data     []interface{}
-	key      string
+	data []interface{}
+	// props[i][k] is the value of the k'th key in data[i], or nil. They're
+	// looked up once, instead of on each comparison, and swapped with the data.
+	props    [][]interface{}
 	nilFirst bool
 }
 
+func newSortableByProperty(data []interface{}, keys []string, nilFirst bool) sortableByProperty {
+	var (
+		props = make([][]interface{}, len(data))
+		flat  = make([]interface{}, len(data)*len(keys))
+		rkeys = make([]reflect.Value, len(keys))
+	)
+	for k, key := range keys {
+		rkeys[k] = reflect.ValueOf(key)
+	}
+	for i, item := range data {
+		props[i] = flat[i*len(keys) : (i+1)*len(keys)]
+		// only maps with string keys have properties
+		rv := reflect.ValueOf(ToLiquid(item))
+		if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+			continue
+		}
+		for k, key := range rkeys {
+			if key.Type() != rv.Type().Key() {
+				key = key.Convert(rv.Type().Key())
+			}
+			if elem := rv.MapIndex(key); elem.IsValid() {
+				props[i][k] = elem.Interface()
+			}
+		}
+	}
+	return sortableByProperty{data, props, nilFirst}
+}
+
 // Len is part of sort.Interface.
 func (s sortableByProperty) Len() int {
 	return len(s.data)
@@ -45,32 +81,26 @@ func (s sortableByProperty) Len() int {
 
 // Swap is part of sort.Interface.
 func (s sortableByProperty) Swap(i, j int) {
-	data := s.data
-	data[i], data[j] = data[j], data[i]
+	s.data[i], s.data[j] = s.data[j], s.data[i]
+	s.props[i], s.props[j] = s.props[j], s.props[i]
 }
 
 // Less is part of sort.Interface.
 func (s sortableByProperty) Less(i, j int) bool {
-	// index returns the value at s.key, if in is a map that contains this key
-	index := func(i int) interface{} {
-		value := ToLiquid(s.data[i])
-		rt := reflect.ValueOf(value)
-		if rt.Kind() == reflect.Map && rt.Type().Key().Kind() == reflect.String {
-			elem := rt.MapIndex(reflect.ValueOf(s.key))
-			if elem.IsValid() {
-				return elem.Interface()
-			}
+	for k := range s.props[i] {
+		a, b := s.props[i][k], s.props[j][k]
+		switch {
+		case a == nil && b == nil:
+			continue
+		case a == nil:
+			return s.nilFirst
+		case b == nil:
+			return !s.nilFirst
+		case Less(a, b):
+			return true
+		case Less(b, a):
+			return false
 		}
-		return nil
-	}
-	a, b := index(i), index(j)
-	switch {
-	case a == nil && b == nil:
-		return false
-	case a == nil:
-		return s.nilFirst
-	case b == nil:
-		return !s.nilFirst
 	}
-	return Less(a, b)
+	return false
 }
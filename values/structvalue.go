@@ -2,6 +2,7 @@ package values
 
 import (
 	"reflect"
+	"sync"
 )
 
 type structValue struct{ wrapperValue }
@@ -15,20 +16,7 @@ func (sv structValue) Contains(elem Value) bool {
 	if !ok {
 		return false
 	}
-	st := reflect.TypeOf(sv.value)
-	if st.Kind() == reflect.Ptr {
-		if _, found := st.MethodByName(name); found {
-			return true
-		}
-		st = st.Elem()
-	}
-	if _, found := st.MethodByName(name); found {
-		return true
-	}
-	if _, found := sv.findField(name); found {
-		return true
-	}
-	return false
+	return lookupStructProperty(reflect.TypeOf(sv.value), name).found()
 }
 
 func (sv structValue) PropertyValue(index Value) Value {
@@ -36,25 +24,22 @@ func (sv structValue) PropertyValue(index Value) Value {
 	if !ok {
 		return nilValue
 	}
+	p := lookupStructProperty(reflect.TypeOf(sv.value), name)
 	sr := reflect.ValueOf(sv.value)
-	st := reflect.TypeOf(sv.value)
-	if st.Kind() == reflect.Ptr {
-		if _, found := st.MethodByName(name); found {
-			m := sr.MethodByName(name)
-			return sv.invoke(m)
+	if sr.Kind() == reflect.Ptr {
+		if p.ptrMethod >= 0 {
+			return sv.invoke(sr.Method(p.ptrMethod))
 		}
-		st = st.Elem()
 		sr = sr.Elem()
 		if !sr.IsValid() {
 			return nilValue
 		}
 	}
-	if _, ok := st.MethodByName(name); ok {
-		m := sr.MethodByName(name)
-		return sv.invoke(m)
-	}
-	if field, ok := sv.findField(name); ok {
-		fv := sr.FieldByName(field.Name)
+	switch {
+	case p.method >= 0:
+		return sv.invoke(sr.Method(p.method))
+	case p.field != nil:
+		fv := sr.FieldByIndex(p.field)
 		if fv.Kind() == reflect.Func {
 			return sv.invoke(fv)
 		}
@@ -63,21 +48,94 @@ func (sv structValue) PropertyValue(index Value) Value {
 	return nilValue
 }
 
+// A structProperty records where a struct type's property is defined, so that
+// the type's methods and fields are only searched once for each name.
+type structProperty struct {
+	ptrMethod int   // the index of a method of the pointer type, or -1
+	method    int   // the index of a method of the struct type, or -1
+	field     []int // the index of the field, or nil
+}
+
+func (p structProperty) found() bool {
+	return p.ptrMethod >= 0 || p.method >= 0 || p.field != nil
+}
+
+// structProperties caches the properties of struct, and pointer to struct,
+// types. It maps a reflect.Type to a map[string]structProperty of the
+// properties that the type defines, so that its size depends only on the
+// number of types, and not on the names that templates look up.
+var structProperties sync.Map
+
+// lookupStructProperty finds the method or field that defines the named
+// property of a struct, or pointer to struct, type.
+func lookupStructProperty(t reflect.Type, name string) structProperty {
+	table, ok := structProperties.Load(t)
+	if !ok {
+		table, _ = structProperties.LoadOrStore(t, structPropertyTable(t))
+	}
+	if p, ok := table.(map[string]structProperty)[name]; ok {
+		return p
+	}
+	return structProperty{ptrMethod: -1, method: -1}
+}
+
+// structPropertyTable returns the properties of a struct, or pointer to
+// struct, type, by name.
+func structPropertyTable(t reflect.Type) map[string]structProperty {
+	var names []string
+	st := t
+	if t.Kind() == reflect.Ptr {
+		for i := 0; i < t.NumMethod(); i++ {
+			names = append(names, t.Method(i).Name)
+		}
+		st = t.Elem()
+	}
+	for i := 0; i < st.NumMethod(); i++ {
+		names = append(names, st.Method(i).Name)
+	}
+	for _, field := range reflect.VisibleFields(st) {
+		names = append(names, field.Name)
+		if name, ok := field.Tag.Lookup(tagKey); ok {
+			names = append(names, name)
+		}
+	}
+	table := map[string]structProperty{}
+	for _, name := range names {
+		if p := findStructProperty(t, name); p.found() {
+			table[name] = p
+		}
+	}
+	return table
+}
+
+// findStructProperty is lookupStructProperty, without the cache.
+func findStructProperty(t reflect.Type, name string) structProperty {
+	p := structProperty{ptrMethod: -1, method: -1}
+	if t.Kind() == reflect.Ptr {
+		if m, ok := t.MethodByName(name); ok {
+			p.ptrMethod = m.Index
+		}
+		t = t.Elem()
+	}
+	if m, ok := t.MethodByName(name); ok {
+		p.method = m.Index
+	} else if field, ok := findField(t, name); ok {
+		p.field = field.Index
+	}
+	return p
+}
+
 const tagKey = "liquid"
 
 // like FieldByName, but obeys `liquid:"name"` tags
-func (sv structValue) findField(name string) (*reflect.StructField, bool) {
-	sr := reflect.TypeOf(sv.value)
-	if sr.Kind() == reflect.Ptr {
-		sr = sr.Elem()
-	}
-	if field, ok := sr.FieldByName(name); ok {
+func findField(st reflect.Type, name string) (*reflect.StructField, bool) {
+	if field, ok := st.FieldByName(name); ok {
 		if _, ok := field.Tag.Lookup(tagKey); !ok {
 			return &field, true
 		}
 	}
-	for i, n := 0, sr.NumField(); i < n; i++ {
-		field := sr.Field(i)
+	for i, n := 0, st.NumField(); i < n; i++ {
+		field := st.Field(i)
 		if field.Tag.Get(tagKey) == name {
 			return &field, true
 		}
@@ -2,6 +2,7 @@ package values
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -89,3 +90,54 @@ func TestValue_struct_ptr(t *testing.T) {
 	require.Equal(t, 4, p.PropertyValue(ValueOf("PM2")).Interface())
 	require.Panics(t, func() { p.PropertyValue(ValueOf("PM2e")) })
 }
+
+func TestValue_struct_race(t *testing.T) {
+	type raceStruct struct{ F, G int }
+	results := make(chan int, 4)
+	for i := 0; i < 4; i++ {
+		go func(i int) {
+			s := ValueOf(&raceStruct{F: i, G: -i})
+			results <- s.PropertyValue(ValueOf("F")).Interface().(int) + s.PropertyValue(ValueOf("G")).Interface().(int)
+		}(i)
+	}
+	for i := 0; i < 4; i++ {
+		require.Equal(t, 0, <-results)
+	}
+}
+
+func TestValue_struct_cache(t *testing.T) {
+	type cacheStruct struct{ F int }
+	s := ValueOf(cacheStruct{F: 1})
+	for i := 0; i < 100; i++ {
+		require.Nil(t, s.PropertyValue(ValueOf(fmt.Sprint("missing", i))).Interface())
+		require.False(t, s.Contains(ValueOf(fmt.Sprint("missing", i))))
+	}
+	require.Equal(t, 1, s.PropertyValue(ValueOf("F")).Interface())
+
+	// the names that aren't properties aren't cached
+	table, ok := structProperties.Load(reflect.TypeOf(cacheStruct{}))
+	require.True(t, ok)
+	require.Len(t, table, 1)
+}
+
+func BenchmarkStructValue_PropertyValue(b *testing.B) {
+	items := make([]interface{}, 1000)
+	for i := range items {
+		if i%2 == 0 {
+			items[i] = testValueStruct{F: i, Renamed: i}
+		} else {
+			items[i] = &testValueStruct{F: i, Renamed: i}
+		}
+	}
+	names := []Value{ValueOf("F"), ValueOf("name"), ValueOf("M1")}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, item := range items {
+			v := ValueOf(item)
+			for _, name := range names {
+				v.PropertyValue(name)
+			}
+		}
+	}
+}
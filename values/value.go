@@ -169,11 +169,18 @@ func (av arrayValue) PropertyValue(iv Value) Value {
 	return nilValue
 }
 
+// Contains returns true if the map has the key. A key is converted to the
+// map's key type if it's assignable to it, as for a map[interface{}]…, or
+// has the same kind, as for a map whose keys are a named string type.
 func (mv mapValue) Contains(iv Value) bool {
 	mr := reflect.ValueOf(mv.value)
 	ir := reflect.ValueOf(iv.Interface())
-	if ir.IsValid() && mr.Type().Key() == ir.Type() {
-		return mr.MapIndex(ir).IsValid()
+	kt := mr.Type().Key()
+	if !ir.IsValid() || !ir.Type().Comparable() {
+		return false
+	}
+	if ir.Type().AssignableTo(kt) || (ir.Kind() == kt.Kind() && ir.Type().ConvertibleTo(kt)) {
+		return mr.MapIndex(ir.Convert(kt)).IsValid()
 	}
 	return false
 }
@@ -197,7 +204,12 @@ func (mv mapValue) PropertyValue(iv Value) Value {
 	if !ir.IsValid() {
 		return nilValue
 	}
-	er := mr.MapIndex(ir)
+	// A map whose keys aren't strings, e.g. map[int]string, has no properties
+	// other than size.
+	var er reflect.Value
+	if ir.Type().AssignableTo(mr.Type().Key()) {
+		er = mr.MapIndex(ir)
+	}
 	switch {
 	case er.IsValid():
 		return ValueOf(er.Interface())
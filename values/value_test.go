@@ -108,6 +108,11 @@ func TestValue_PropertyValue(t *testing.T) {
 	hv = ValueOf(map[interface{}]interface{}{"key": "value"})
 	require.Equal(t, "value", hv.PropertyValue(ValueOf("key")).Interface())
 
+	// non-string keys
+	hv = ValueOf(map[int]string{1: "value"})
+	require.Nil(t, hv.PropertyValue(ValueOf("key")).Interface())
+	require.Equal(t, 1, hv.PropertyValue(ValueOf("size")).Interface())
+
 	// ptr to map
 	hashPtr := ValueOf(&map[string]interface{}{"key": "value"})
 	require.Equal(t, "value", hashPtr.PropertyValue(ValueOf("key")).Interface())
@@ -146,6 +151,14 @@ func TestValue_Contains(t *testing.T) {
 	require.True(t, hv.Contains(ValueOf("key")))
 	require.False(t, hv.Contains(ValueOf("missing_key")))
 	require.False(t, hv.Contains(ValueOf(nil)))
+	require.False(t, hv.Contains(ValueOf([]string{"key"})))
+
+	// the key is converted to the map's key type, but not from another kind
+	type key string
+	require.True(t, ValueOf(map[interface{}]int{"key": 1, 2: 2}).Contains(ValueOf("key")))
+	require.True(t, ValueOf(map[interface{}]int{"key": 1, 2: 2}).Contains(ValueOf(2)))
+	require.True(t, ValueOf(map[key]int{"key": 1}).Contains(ValueOf("key")))
+	require.False(t, ValueOf(map[string]int{"a": 1}).Contains(ValueOf(97)))
 
 	// MapSlice
 	msv := ValueOf(yaml.MapSlice{{Key: "key", Value: "value"}})